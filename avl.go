@@ -2,118 +2,100 @@ package forest
 
 import (
 	"fmt"
+	"iter"
+	"reflect"
 
 	"golang.org/x/exp/constraints"
 )
 
-type avlNode[K constraints.Ordered, V any] struct {
+type avlNode[K any, V any] struct {
 	parent *avlNode[K, V]
 	split  K
 	left   *avlNode[K, V]
 	right  *avlNode[K, V]
 	val    V
+	height int
+	size   int
 }
 
-func newAVLNode[K constraints.Ordered, V any](parent *avlNode[K, V], split K, value V) *avlNode[K, V] {
+func newAVLNode[K any, V any](parent *avlNode[K, V], split K, value V) *avlNode[K, V] {
 	return &avlNode[K, V]{
 		parent: parent,
 		split:  split,
 		val:    value,
+		height: 1,
+		size:   1,
 	}
 }
 
-func (n *avlNode[K, V]) insertAndBalance(key K, value V) (root *avlNode[K, V], maybeUnbalanced bool, err error) {
-	switch {
-	case key < n.split:
-		if n.left == nil {
-			n.left = newAVLNode(n, key, value)
-			return n, true, nil
-		}
-		root, maybeUnbalanced, err := n.left.insertAndBalance(key, value)
-		if err != nil || !maybeUnbalanced {
-			return root, false, err
-		}
-		root, more := n.balance()
-		return root, more, nil
-	case key > n.split:
-		if n.right == nil {
-			n.right = newAVLNode(n, key, value)
-			return n, true, nil
-		}
-		root, maybeUnbalanced, err := n.right.insertAndBalance(key, value)
-		if err != nil || !maybeUnbalanced {
-			return root, false, err
-		}
-		root, more := n.balance()
-		return root, more, nil
-	default:
-		return nil, false, fmt.Errorf("key already exist: %v", key)
-	}
-}
+// balance updates the height and the size of a node whose subtrees have changed and rotates the node when it is
+// unbalanced. This function returns a new root of the subtree and whether the height of the subtree has changed.
+func (n *avlNode[K, V]) balance(augment avlAugment[K, V]) (root *avlNode[K, V], changed bool) {
+	h := n.height
+	n.update(augment)
 
-func (n *avlNode[K, V]) balance() (root *avlNode[K, V], more bool) {
 	bf := n.balanceFactor()
-	if bf == 0 {
-		return n, false
-	} else if bf == -1 || bf == 1 {
-		return n, true
-	}
-
-	// balance
-	if bf < 0 {
+	switch {
+	case bf < -1:
 		// left-heavy
-		if n.left.leftHight() < n.left.rightHight() {
-			n.left.rotateLeft()
-			root = n.rotateRight()
-		} else {
-			root = n.rotateRight()
+		if n.left.balanceFactor() > 0 {
+			n.left.rotateLeft(augment)
 		}
-	} else {
+		root = n.rotateRight(augment)
+	case bf > 1:
 		// right-heavy
-		if n.right.leftHight() > n.right.rightHight() {
-			n.right.rotateRight()
-			root = n.rotateLeft()
-		} else {
-			root = n.rotateLeft()
+		if n.right.balanceFactor() < 0 {
+			n.right.rotateRight(augment)
 		}
+		root = n.rotateLeft(augment)
+	default:
+		root = n
 	}
-	return root, true
+	return root, root.height != h
 }
 
 func (n *avlNode[K, V]) balanceFactor() int {
-	return n.rightHight() - n.leftHight()
+	return n.right.subtreeHeight() - n.left.subtreeHeight()
 }
 
-func (n *avlNode[K, V]) leftHight() int {
-	if n.left == nil {
+// subtreeHeight returns the height of a subtree. A nil node is treated as an empty subtree whose height is 0.
+func (n *avlNode[K, V]) subtreeHeight() int {
+	if n == nil {
 		return 0
 	}
-	return n.left.hight() + 1
+	return n.height
 }
 
-func (n *avlNode[K, V]) rightHight() int {
-	if n.right == nil {
+// subtreeSize returns the number of nodes in a subtree. A nil node is treated as an empty subtree.
+func (n *avlNode[K, V]) subtreeSize() int {
+	if n == nil {
 		return 0
 	}
-	return n.right.hight() + 1
+	return n.size
 }
 
-func (n *avlNode[K, V]) hight() int {
-	var l, r int
-	if n.left != nil {
-		l = n.left.hight() + 1
-	}
-	if n.right != nil {
-		r = n.right.hight() + 1
-	}
+// avlAugment recomputes information that the value of a node caches about its subtree, such as the aggregates of
+// AggregateAVLTree, from the children of the node. Functions restructuring a tree take it as an argument and call it
+// wherever they update a node, so rotations and deletions maintain the information without further code. Plain trees
+// pass nil, which costs them only a nil check.
+type avlAugment[K any, V any] func(n *avlNode[K, V])
+
+// update recomputes the height and the size of a subtree from its children, and calls `augment` unless it is nil.
+func (n *avlNode[K, V]) update(augment avlAugment[K, V]) {
+	l, r := n.left.subtreeHeight(), n.right.subtreeHeight()
 	if l > r {
-		return l
+		n.height = l + 1
+	} else {
+		n.height = r + 1
+	}
+	n.size = n.left.subtreeSize() + n.right.subtreeSize() + 1
+	if augment != nil {
+		augment(n)
 	}
-	return r
 }
 
 // rotateLeft rotates a tree left.
-func (n *avlNode[K, V]) rotateLeft() *avlNode[K, V] {
+func (n *avlNode[K, V]) rotateLeft(augment avlAugment[K, V]) *avlNode[K, V] {
 	// A          B
 	//  \        / \
 	//   B   => A   D
@@ -137,6 +119,8 @@ func (n *avlNode[K, V]) rotateLeft() *avlNode[K, V] {
 	}
 	n.right = pivot.left
 	pivot.left = n
+	n.update(augment)
+	pivot.update(augment)
 
 	if parent != nil {
 		*parent = pivot
@@ -146,7 +130,7 @@ func (n *avlNode[K, V]) rotateLeft() *avlNode[K, V] {
 }
 
 // rotateRight rotates a tree right.
-func (n *avlNode[K, V]) rotateRight() *avlNode[K, V] {
+func (n *avlNode[K, V]) rotateRight(augment avlAugment[K, V]) *avlNode[K, V] {
 	//     A      B
 	//    /      / \
 	//   B   => C   A
@@ -170,6 +154,8 @@ func (n *avlNode[K, V]) rotateRight() *avlNode[K, V] {
 	}
 	n.left = pivot.right
 	pivot.right = n
+	n.update(augment)
+	pivot.update(augment)
 
 	if parent != nil {
 		*parent = pivot
@@ -178,214 +164,668 @@ func (n *avlNode[K, V]) rotateRight() *avlNode[K, V] {
 	return pivot
 }
 
-func (n *avlNode[K, V]) search(key K) (node *avlNode[K, V], found bool) {
-	switch {
-	case key < n.split:
-		if n.left == nil {
-			return
-		}
-		return n.left.search(key)
-	case key > n.split:
-		if n.right == nil {
-			return
+// rebalanceUpward restores the balance of nodes on a path from a node to a root and returns the root.
+func (n *avlNode[K, V]) rebalanceUpward(augment avlAugment[K, V]) *avlNode[K, V] {
+	for {
+		root, _ := n.balance(augment)
+		if root.parent == nil {
+			return root
 		}
-		return n.right.search(key)
-	default:
-		return n, true
+		n = root.parent
 	}
 }
 
-func (n *avlNode[K, V]) deleteAndBalance(key K) (root *avlNode[K, V], value V, found bool) {
-	switch {
-	case key < n.split:
-		if n.left == nil {
-			return
+// locate descends a subtree to a node having `key` and returns the node and 0. When `key` doesn't exist, this function
+// returns the node under which a node having `key` should be inserted and the result of comparing `key` with the key
+// of the node. When the subtree is empty, the node is nil.
+func (n *avlNode[K, V]) locate(cmp func(a, b K) int, key K) (node *avlNode[K, V], c int) {
+	for n != nil {
+		c = cmp(key, n.split)
+		next := n.right
+		switch {
+		case c < 0:
+			next = n.left
+		case c == 0:
+			return n, 0
 		}
-		root, value, found = n.left.deleteAndBalance(key)
-		if !found {
-			return
+		if next == nil {
+			return n, c
 		}
-		root, _ = n.balanceOnDeletion()
-		return
-	case key > n.split:
-		if n.right == nil {
-			return
-		}
-		root, value, found = n.right.deleteAndBalance(key)
-		if !found {
-			return
-		}
-		root, _ = n.balanceOnDeletion()
-		return
+		n = next
 	}
+	return nil, 0
+}
 
-	d := n
+// locateOrdered is like locate but compares keys using < and > directly instead of calling a comparison function.
+// Trees created by NewAVLTree use it to avoid an indirect call on every visited node.
+func locateOrdered[K constraints.Ordered, V any](n *avlNode[K, V], key K) (node *avlNode[K, V], c int) {
+	for n != nil {
+		switch {
+		case key < n.split:
+			if n.left == nil {
+				return n, -1
+			}
+			n = n.left
+		case key > n.split:
+			if n.right == nil {
+				return n, 1
+			}
+			n = n.right
+		default:
+			return n, 0
+		}
+	}
+	return nil, 0
+}
 
-	val := d.val
+// insertChild attaches a new node having an entry to a node as its left child when `c` is negative or as its right
+// child otherwise, and returns a new root of the whole tree. When the node is nil, the new node becomes the root.
+func (n *avlNode[K, V]) insertChild(c int, key K, value V, augment avlAugment[K, V]) *avlNode[K, V] {
+	child := newAVLNode(n, key, value)
 	switch {
-	case d.left == nil && d.right == nil:
-		if d.parent != nil {
-			if d.parent.left == d {
-				d.parent.left = nil
-			} else {
-				d.parent.right = nil
+	case n == nil:
+		return child
+	case c < 0:
+		n.left = child
+	default:
+		n.right = child
+	}
+	return n.rebalanceUpward(augment)
+}
+
+// remove removes the entry of a node from the tree and returns a new root of the whole tree, which is nil when
+// the tree becomes empty. When the node has two children, it takes over the entry of its predecessor, and the node of
+// the predecessor is removed instead. The removed node is detached from the tree.
+func (n *avlNode[K, V]) remove(augment avlAugment[K, V]) *avlNode[K, V] {
+	if n.left != nil && n.right != nil {
+		pred := n.left.rightmost()
+		n.split, n.val = pred.split, pred.val
+		n = pred
+	}
+
+	child := n.left
+	if child == nil {
+		child = n.right
+	}
+	p := n.parent
+	if child != nil {
+		child.parent = p
+	}
+	n.parent, n.left, n.right = nil, nil, nil
+	if p == nil {
+		return child
+	}
+	if p.left == n {
+		p.left = child
+	} else {
+		p.right = child
+	}
+	return p.rebalanceUpward(augment)
+}
+
+// leftmost returns a node having the minimum key in a subtree.
+func (n *avlNode[K, V]) leftmost() *avlNode[K, V] {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// rightmost returns a node having the maximum key in a subtree.
+func (n *avlNode[K, V]) rightmost() *avlNode[K, V] {
+	for n.right != nil {
+		n = n.right
+	}
+	return n
+}
+
+// successor returns a node having the next larger key. When such a node doesn't exist, this function returns nil.
+func (n *avlNode[K, V]) successor() *avlNode[K, V] {
+	if n.right != nil {
+		return n.right.leftmost()
+	}
+	for n.parent != nil && n.parent.right == n {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// predecessor returns a node having the next smaller key. When such a node doesn't exist, this function returns nil.
+func (n *avlNode[K, V]) predecessor() *avlNode[K, V] {
+	if n.left != nil {
+		return n.left.rightmost()
+	}
+	for n.parent != nil && n.parent.left == n {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// entry returns a key and a value of a node. When the node is nil, `found` is false.
+func (n *avlNode[K, V]) entry() (key K, value V, found bool) {
+	if n == nil {
+		return
+	}
+	return n.split, n.val, true
+}
+
+// ceiling returns a node having the smallest key that is greater than or equal to a specified key.
+// When `inclusive` is false, a node having the same key as the specified one is skipped.
+func (n *avlNode[K, V]) ceiling(cmp func(a, b K) int, key K, inclusive bool) *avlNode[K, V] {
+	var ceil *avlNode[K, V]
+	for n != nil {
+		switch c := cmp(key, n.split); {
+		case c < 0:
+			ceil = n
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			if inclusive {
+				return n
 			}
+			n = n.right
 		}
+	}
+	return ceil
+}
 
-		if d == n {
-			root = nil
-		} else {
-			root = n
+// floor returns a node having the largest key that is less than or equal to a specified key.
+// When `inclusive` is false, a node having the same key as the specified one is skipped.
+func (n *avlNode[K, V]) floor(cmp func(a, b K) int, key K, inclusive bool) *avlNode[K, V] {
+	var f *avlNode[K, V]
+	for n != nil {
+		switch c := cmp(key, n.split); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			f = n
+			n = n.right
+		default:
+			if inclusive {
+				return n
+			}
+			n = n.left
 		}
-	case d.left != nil && d.right == nil:
-		alt := d.left
-		alt.parent = d.parent
-		*d = *alt
+	}
+	return f
+}
 
-		if d == n {
-			root = alt
-		} else {
-			root = n
+// rank returns the number of nodes having keys less than a specified key.
+func (n *avlNode[K, V]) rank(cmp func(a, b K) int, key K) (rank int, found bool) {
+	for n != nil {
+		switch c := cmp(key, n.split); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			rank += n.left.subtreeSize() + 1
+			n = n.right
+		default:
+			return rank + n.left.subtreeSize(), true
 		}
-	case d.left == nil && d.right != nil:
-		alt := d.right
-		alt.parent = d.parent
-		*d = *alt
+	}
+	return rank, false
+}
 
-		if d == n {
-			root = alt
-		} else {
-			root = n
+// nth returns a node having the i-th smallest key (0-origin). When `i` is out of range, this function returns nil.
+func (n *avlNode[K, V]) nth(i int) *avlNode[K, V] {
+	if i < 0 || i >= n.subtreeSize() {
+		return nil
+	}
+	for n != nil {
+		l := n.left.subtreeSize()
+		switch {
+		case i < l:
+			n = n.left
+		case i > l:
+			i -= l + 1
+			n = n.right
+		default:
+			return n
 		}
+	}
+	return nil
+}
+
+// AVLTree is an AVL tree mapping keys of type `K` to values of type `V`.
+//
+// The zero value is an empty tree ordering keys in their natural order, which is available when `K` is an ordered
+// type such as int, float64, string, or time.Duration. Keys of named types are compared through reflection, so
+// NewAVLTree creates a faster tree for them. For key types that aren't ordered, create a tree using NewAVLTreeFunc;
+// operations on the zero value panic.
+type AVLTree[K any, V any] struct {
+	root *avlNode[K, V]
+
+	// cmp orders keys. When it is nil, keys are ordered in their natural order (see naturalOrder).
+	cmp func(a, b K) int
+
+	// locate is locateOrdered specialized for `K`. It is nil unless keys can be compared using < and >, in which case
+	// lookups use it instead of calling cmp on every visited node.
+	locate func(n *avlNode[K, V], key K) (*avlNode[K, V], int)
+}
+
+// NewAVLTree returns a new AVL tree that can contain entries mapping `K` to `V`.
+func NewAVLTree[K constraints.Ordered, V any]() *AVLTree[K, V] {
+	return &AVLTree[K, V]{
+		cmp:    compareOrdered[K],
+		locate: locateOrdered[K, V],
+	}
+}
+
+// NewAVLTreeFunc returns a new AVL tree that orders keys using `cmp`. `cmp` must return a negative number when a < b,
+// a positive number when a > b, and zero when a == b, and it must define a strict weak ordering.
+func NewAVLTreeFunc[K any, V any](cmp func(a, b K) int) *AVLTree[K, V] {
+	return &AVLTree[K, V]{
+		cmp: cmp,
+	}
+}
+
+func compareOrdered[K constraints.Ordered](a, b K) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// naturalOrder returns functions ordering keys in their natural order when `K` is an ordered type, that is, a type
+// satisfying constraints.Ordered. `locate` is nil unless `K` is a predeclared type. When `K` isn't ordered, this
+// function returns nil functions.
+func naturalOrder[K any, V any]() (cmp func(a, b K) int, locate func(n *avlNode[K, V], key K) (*avlNode[K, V], int)) {
+	var c, l any
+	switch any(*new(K)).(type) {
+	case int:
+		c, l = compareOrdered[int], locateOrdered[int, V]
+	case int8:
+		c, l = compareOrdered[int8], locateOrdered[int8, V]
+	case int16:
+		c, l = compareOrdered[int16], locateOrdered[int16, V]
+	case int32:
+		c, l = compareOrdered[int32], locateOrdered[int32, V]
+	case int64:
+		c, l = compareOrdered[int64], locateOrdered[int64, V]
+	case uint:
+		c, l = compareOrdered[uint], locateOrdered[uint, V]
+	case uint8:
+		c, l = compareOrdered[uint8], locateOrdered[uint8, V]
+	case uint16:
+		c, l = compareOrdered[uint16], locateOrdered[uint16, V]
+	case uint32:
+		c, l = compareOrdered[uint32], locateOrdered[uint32, V]
+	case uint64:
+		c, l = compareOrdered[uint64], locateOrdered[uint64, V]
+	case uintptr:
+		c, l = compareOrdered[uintptr], locateOrdered[uintptr, V]
+	case float32:
+		c, l = compareOrdered[float32], locateOrdered[float32, V]
+	case float64:
+		c, l = compareOrdered[float64], locateOrdered[float64, V]
+	case string:
+		c, l = compareOrdered[string], locateOrdered[string, V]
 	default:
-		alt, leftRoot, shrinked := d.left.extractMax()
-		d.split = alt.split
-		d.val = alt.val
-		d.left = leftRoot
-
-		var r *avlNode[K, V]
-		if shrinked {
-			r, _ = d.balanceOnDeletion()
-		} else {
-			r = d
-		}
+		return namedOrder[K](), nil
+	}
+	return c.(func(a, b K) int), l.(func(n *avlNode[K, V], key K) (*avlNode[K, V], int))
+}
 
-		if d == n {
-			root = r
-		} else {
-			root = n
+// namedOrder returns a function ordering keys of a named type, such as time.Duration, by the natural order of the
+// underlying type. Keys are compared through reflection, which is slower than the comparison of predeclared types.
+// When the underlying type isn't ordered, this function returns nil.
+func namedOrder[K any]() func(a, b K) int {
+	switch reflect.TypeFor[K]().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b K) int {
+			return compareOrdered(reflect.ValueOf(a).Int(), reflect.ValueOf(b).Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b K) int {
+			return compareOrdered(reflect.ValueOf(a).Uint(), reflect.ValueOf(b).Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(a, b K) int {
+			return compareOrdered(reflect.ValueOf(a).Float(), reflect.ValueOf(b).Float())
+		}
+	case reflect.String:
+		return func(a, b K) int {
+			return compareOrdered(reflect.ValueOf(a).String(), reflect.ValueOf(b).String())
 		}
 	}
-	return root, val, true
+	return nil
 }
 
-func (n *avlNode[K, V]) extractMax() (max *avlNode[K, V], root *avlNode[K, V], shrinked bool) {
-	if n.right == nil {
-		max = &avlNode[K, V]{
-			split: n.split,
-			val:   n.val,
-		}
-		if n.left != nil {
-			n.left.parent = n.parent
-			*n = *n.left
-		} else {
-			if n.parent != nil {
-				if n.parent.left == n {
-					n.parent.left = nil
-				} else {
-					n.parent.right = nil
-				}
-			}
+// ordering returns functions ordering keys of the tree. It panics when the tree is the zero value and `K` has no
+// natural order.
+func (t *AVLTree[K, V]) ordering() (cmp func(a, b K) int, locate func(n *avlNode[K, V], key K) (*avlNode[K, V], int)) {
+	if t.cmp != nil {
+		return t.cmp, t.locate
+	}
+	cmp, locate = naturalOrder[K, V]()
+	if cmp == nil {
+		panic(fmt.Sprintf("forest: keys of type %T have no natural order; create the AVLTree using NewAVLTreeFunc", *new(K)))
+	}
+	return cmp, locate
+}
+
+// compare returns a function ordering keys of the tree.
+func (t *AVLTree[K, V]) compare() func(a, b K) int {
+	cmp, _ := t.ordering()
+	return cmp
+}
+
+// find descends the tree in the same way as avlNode.locate.
+func (t *AVLTree[K, V]) find(key K) (node *avlNode[K, V], c int) {
+	cmp, locate := t.ordering()
+	if locate != nil {
+		return locate(t.root, key)
+	}
+	return t.root.locate(cmp, key)
+}
+
+// withRoot returns a new tree that has `root` and orders keys in the same way as the tree.
+func (t *AVLTree[K, V]) withRoot(root *avlNode[K, V]) *AVLTree[K, V] {
+	return &AVLTree[K, V]{
+		root:   root,
+		cmp:    t.cmp,
+		locate: t.locate,
+	}
+}
+
+// Insert inserts an entry. When the key already exists, this function returns a *KeyError wrapping ErrKeyExists.
+func (t *AVLTree[K, V]) Insert(key K, value V) error {
+	n, c := t.find(key)
+	if n != nil && c == 0 {
+		return &KeyError{
+			Key: key,
+			Err: ErrKeyExists,
 		}
-		return max, nil, true
 	}
-	max, _, _ = n.right.extractMax()
-	root, shrinked = n.balanceOnDeletion()
-	return max, root, shrinked
+	t.root = n.insertChild(c, key, value, nil)
+	return nil
 }
 
-func (n *avlNode[K, V]) balanceOnDeletion() (root *avlNode[K, V], shrinked bool) {
-	bf := n.balanceFactor()
-	if bf == 0 {
-		return n, false
-	} else if bf == -1 || bf == 1 {
-		return n, false
+// Search earches for an entry having a key that exactly matches a specified key and returns its value.
+func (t *AVLTree[K, V]) Search(key K) (value V, found bool) {
+	n, c := t.find(key)
+	if n == nil || c != 0 {
+		return
 	}
+	return n.val, true
+}
 
-	h := n.hight()
+// Delete deletes an entry and returns its value.
+func (t *AVLTree[K, V]) Delete(key K) (value V, found bool) {
+	n, c := t.find(key)
+	if n == nil || c != 0 {
+		return
+	}
+	value = n.val
+	t.root = n.remove(nil)
+	return value, true
+}
 
-	// balance
-	if bf < 0 {
-		// left-heavy
-		if n.left.leftHight() < n.left.rightHight() {
-			n.left.rotateLeft()
-			root = n.rotateRight()
-		} else {
-			root = n.rotateRight()
+// All returns an iterator over entries in ascending order of keys.
+// The tree must not be modified during the iteration.
+func (t *AVLTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
 		}
-	} else {
-		// right-heavy
-		if n.right.leftHight() > n.right.rightHight() {
-			n.right.rotateRight()
-			root = n.rotateLeft()
-		} else {
-			root = n.rotateLeft()
+		for n := t.root.leftmost(); n != nil; n = n.successor() {
+			if !yield(n.split, n.val) {
+				return
+			}
 		}
 	}
+}
 
-	if root.hight() < h {
-		shrinked = true
+// Backward returns an iterator over entries in descending order of keys.
+// The tree must not be modified during the iteration.
+func (t *AVLTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		for n := t.root.rightmost(); n != nil; n = n.predecessor() {
+			if !yield(n.split, n.val) {
+				return
+			}
+		}
 	}
+}
 
-	return root, shrinked
+// Keys returns an iterator over keys in ascending order.
+// The tree must not be modified during the iteration.
+func (t *AVLTree[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range t.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
 }
 
-type AVLTree[K constraints.Ordered, V any] struct {
-	root *avlNode[K, V]
+// Values returns an iterator over values in ascending order of their keys.
+// The tree must not be modified during the iteration.
+func (t *AVLTree[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range t.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
 }
 
-// NewAVLTree returns a new AVL tree that can contain entries mapping `K` to `V`.
-func NewAVLTree[K constraints.Ordered, V any]() *AVLTree[K, V] {
-	return &AVLTree[K, V]{}
+// RangeBound specifies how an end of a range is treated.
+type RangeBound int
+
+const (
+	// Inclusive makes a range contain its end.
+	Inclusive RangeBound = iota
+	// Exclusive makes a range exclude its end.
+	Exclusive
+	// Unbounded ignores an end of a range.
+	Unbounded
+)
+
+// RangeOptions specifies a range query. The zero value represents a closed range [lo, hi] traversed in ascending order.
+type RangeOptions struct {
+	Low        RangeBound
+	High       RangeBound
+	Descending bool
 }
 
-// Insert inserts an entry. When the key already exists, this function return an error.
-func (t *AVLTree[K, V]) Insert(key K, value V) error {
-	if t.root == nil {
-		t.root = newAVLNode(nil, key, value)
-		return nil
+// rangeBounds returns predicates reporting whether a key satisfies the lower end and the upper end of a range.
+func rangeBounds[K any](cmp func(a, b K) int, lo, hi K, opts RangeOptions) (aboveLow, belowHigh func(key K) bool) {
+	aboveLow = func(key K) bool {
+		switch opts.Low {
+		case Inclusive:
+			return cmp(key, lo) >= 0
+		case Exclusive:
+			return cmp(key, lo) > 0
+		}
+		return true
 	}
-	root, _, err := t.root.insertAndBalance(key, value)
-	if err != nil {
-		return err
+	belowHigh = func(key K) bool {
+		switch opts.High {
+		case Inclusive:
+			return cmp(key, hi) <= 0
+		case Exclusive:
+			return cmp(key, hi) < 0
+		}
+		return true
 	}
-	if root.parent == nil {
-		t.root = root
+	return aboveLow, belowHigh
+}
+
+// Range returns an iterator over entries whose keys are between `lo` and `hi`.
+// `opts` specifies whether each end is inclusive, exclusive, or unbounded, and the order of the iteration.
+// The tree must not be modified during the iteration.
+func (t *AVLTree[K, V]) Range(lo, hi K, opts RangeOptions) iter.Seq2[K, V] {
+	cmp := t.compare()
+	aboveLow, belowHigh := rangeBounds(cmp, lo, hi, opts)
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		if opts.Descending {
+			var n *avlNode[K, V]
+			if opts.High == Unbounded {
+				n = t.root.rightmost()
+			} else {
+				n = t.root.floor(cmp, hi, opts.High == Inclusive)
+			}
+			for ; n != nil && aboveLow(n.split); n = n.predecessor() {
+				if !yield(n.split, n.val) {
+					return
+				}
+			}
+			return
+		}
+		var n *avlNode[K, V]
+		if opts.Low == Unbounded {
+			n = t.root.leftmost()
+		} else {
+			n = t.root.ceiling(cmp, lo, opts.Low == Inclusive)
+		}
+		for ; n != nil && belowHigh(n.split); n = n.successor() {
+			if !yield(n.split, n.val) {
+				return
+			}
+		}
 	}
-	return nil
 }
 
-// Search earches for an entry having a key that exactly matches a specified key and returns its value.
-func (t *AVLTree[K, V]) Search(key K) (value V, found bool) {
+// Min returns an entry having the minimum key.
+func (t *AVLTree[K, V]) Min() (key K, value V, found bool) {
 	if t.root == nil {
 		return
 	}
-	n, ok := t.root.search(key)
-	if !ok {
+	return t.root.leftmost().entry()
+}
+
+// Max returns an entry having the maximum key.
+func (t *AVLTree[K, V]) Max() (key K, value V, found bool) {
+	if t.root == nil {
 		return
 	}
-	return n.val, true
+	return t.root.rightmost().entry()
 }
 
-// Delete deletes an entry and returns its value.
-func (t *AVLTree[K, V]) Delete(key K) (value V, found bool) {
+// PopMin deletes an entry having the minimum key and returns it.
+func (t *AVLTree[K, V]) PopMin() (key K, value V, found bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.root.leftmost()
+	key, value, found = n.entry()
+	t.root = n.remove(nil)
+	return key, value, found
+}
+
+// PopMax deletes an entry having the maximum key and returns it.
+func (t *AVLTree[K, V]) PopMax() (key K, value V, found bool) {
 	if t.root == nil {
 		return
 	}
-	root, v, ok := t.root.deleteAndBalance(key)
-	if !ok {
+	n := t.root.rightmost()
+	key, value, found = n.entry()
+	t.root = n.remove(nil)
+	return key, value, found
+}
+
+// Floor returns an entry having the largest key that is less than or equal to a specified key.
+func (t *AVLTree[K, V]) Floor(key K) (k K, value V, found bool) {
+	return t.root.floor(t.compare(), key, true).entry()
+}
+
+// Ceiling returns an entry having the smallest key that is greater than or equal to a specified key.
+func (t *AVLTree[K, V]) Ceiling(key K) (k K, value V, found bool) {
+	return t.root.ceiling(t.compare(), key, true).entry()
+}
+
+// Lower returns an entry having the largest key that is strictly less than a specified key.
+func (t *AVLTree[K, V]) Lower(key K) (k K, value V, found bool) {
+	return t.root.floor(t.compare(), key, false).entry()
+}
+
+// Higher returns an entry having the smallest key that is strictly greater than a specified key.
+func (t *AVLTree[K, V]) Higher(key K) (k K, value V, found bool) {
+	return t.root.ceiling(t.compare(), key, false).entry()
+}
+
+// Len returns the number of entries.
+func (t *AVLTree[K, V]) Len() int {
+	return t.root.subtreeSize()
+}
+
+// Rank returns the number of entries having keys less than a specified key, that is, the 0-origin position of the key
+// in ascending order. `found` reports whether the key exists.
+func (t *AVLTree[K, V]) Rank(key K) (rank int, found bool) {
+	return t.root.rank(t.compare(), key)
+}
+
+// Select returns an entry having the i-th smallest key (0-origin).
+func (t *AVLTree[K, V]) Select(i int) (key K, value V, found bool) {
+	return t.root.nth(i).entry()
+}
+
+// Update inserts, modifies, or deletes an entry in a single descent. `fn` receives the current value and whether
+// the entry exists, and returns a new value and whether the entry should be kept. When `keep` is false, the entry is
+// deleted if it exists and isn't inserted otherwise.
+func (t *AVLTree[K, V]) Update(key K, fn func(old V, exists bool) (value V, keep bool)) {
+	n, c := t.find(key)
+	if n != nil && c == 0 {
+		v, keep := fn(n.val, true)
+		if !keep {
+			t.root = n.remove(nil)
+			return
+		}
+		n.val = v
+		return
+	}
+	var zero V
+	v, keep := fn(zero, false)
+	if keep {
+		t.root = n.insertChild(c, key, v, nil)
+	}
+}
+
+// Put inserts an entry or overwrites the value of an existing entry. When the key already exists, this function
+// returns the previous value.
+func (t *AVLTree[K, V]) Put(key K, value V) (old V, replaced bool) {
+	t.Update(key, func(v V, exists bool) (V, bool) {
+		old, replaced = v, exists
+		return value, true
+	})
+	return old, replaced
+}
+
+// Replace overwrites the value of an existing entry and returns the previous value. When the key doesn't exist,
+// this function does nothing.
+func (t *AVLTree[K, V]) Replace(key K, value V) (old V, found bool) {
+	n, c := t.find(key)
+	if n == nil || c != 0 {
 		return
 	}
-	t.root = root
-	return v, true
+	old = n.val
+	n.val = value
+	return old, true
+}
+
+// GetOrInsert returns the value of an existing entry. When the key doesn't exist, this function inserts an entry
+// with a value that `newValue` returns. `inserted` reports whether the entry was inserted.
+func (t *AVLTree[K, V]) GetOrInsert(key K, newValue func() V) (value V, inserted bool) {
+	t.Update(key, func(v V, exists bool) (V, bool) {
+		if !exists {
+			v = newValue()
+			inserted = true
+		}
+		value = v
+		return v, true
+	})
+	return value, inserted
 }
@@ -0,0 +1,207 @@
+package forest
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Monoid specifies how values of an AggregateAVLTree are aggregated. `Measure` maps a value to an element of the
+// monoid, and `Combine` must be associative and have `Identity` as its identity element. `Combine` doesn't need to be
+// commutative; elements are always combined in ascending order of keys.
+type Monoid[V any, A any] struct {
+	Identity A
+	Measure  func(value V) A
+	Combine  func(a, b A) A
+}
+
+// aggregateEntry is a value of the AVLTree underlying an AggregateAVLTree. It caches the aggregate of all values in
+// the subtree of its node.
+type aggregateEntry[V any, A any] struct {
+	val V
+	agg A
+}
+
+// aggregateAugment returns an avlAugment that recomputes the aggregate of a node from its children. AggregateAVLTree
+// passes it to the functions restructuring the tree, so insertion, deletion, and rotations of AVLTree maintain
+// aggregates without code specific to this tree.
+func aggregateAugment[K any, V any, A any](m *Monoid[V, A]) avlAugment[K, aggregateEntry[V, A]] {
+	return func(n *avlNode[K, aggregateEntry[V, A]]) {
+		agg := m.Measure(n.val.val)
+		if n.left != nil {
+			agg = m.Combine(n.left.val.agg, agg)
+		}
+		if n.right != nil {
+			agg = m.Combine(agg, n.right.val.agg)
+		}
+		n.val.agg = agg
+	}
+}
+
+// subtreeAggregate returns the aggregate of a subtree. A nil node is treated as an empty subtree whose aggregate is
+// the identity element.
+func subtreeAggregate[K any, V any, A any](m *Monoid[V, A], n *avlNode[K, aggregateEntry[V, A]]) A {
+	if n == nil {
+		return m.Identity
+	}
+	return n.val.agg
+}
+
+// aggregateFrom returns the aggregate of values in a subtree whose keys satisfy `aboveLow`. Because such keys form
+// a suffix of the subtree, this function visits only one path from the root.
+func aggregateFrom[K any, V any, A any](m *Monoid[V, A], n *avlNode[K, aggregateEntry[V, A]], aboveLow func(K) bool) A {
+	// `acc` holds the aggregate of values greater than every key in the current subtree.
+	acc := m.Identity
+	for n != nil {
+		if aboveLow(n.split) {
+			acc = m.Combine(m.Combine(m.Measure(n.val.val), subtreeAggregate(m, n.right)), acc)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return acc
+}
+
+// aggregateTo returns the aggregate of values in a subtree whose keys satisfy `belowHigh`. Such keys form a prefix
+// of the subtree.
+func aggregateTo[K any, V any, A any](m *Monoid[V, A], n *avlNode[K, aggregateEntry[V, A]], belowHigh func(K) bool) A {
+	// `acc` holds the aggregate of values less than every key in the current subtree.
+	acc := m.Identity
+	for n != nil {
+		if belowHigh(n.split) {
+			acc = m.Combine(acc, m.Combine(subtreeAggregate(m, n.left), m.Measure(n.val.val)))
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return acc
+}
+
+// AggregateAVLTree is an AVL tree in which every node caches the aggregate of the values in its subtree under
+// a user-supplied monoid, so the aggregate of any key range, such as a sum, a minimum, or a maximum, can be computed
+// in O(log n) time.
+type AggregateAVLTree[K any, V any, A any] struct {
+	tree    *AVLTree[K, aggregateEntry[V, A]]
+	m       *Monoid[V, A]
+	augment avlAugment[K, aggregateEntry[V, A]]
+}
+
+// NewAggregateAVLTree returns a new empty aggregate AVL tree that aggregates values using `m`.
+func NewAggregateAVLTree[K constraints.Ordered, V any, A any](m Monoid[V, A]) *AggregateAVLTree[K, V, A] {
+	return &AggregateAVLTree[K, V, A]{
+		tree:    NewAVLTree[K, aggregateEntry[V, A]](),
+		m:       &m,
+		augment: aggregateAugment[K](&m),
+	}
+}
+
+// NewAggregateAVLTreeFunc returns a new empty aggregate AVL tree that orders keys using `cmp` and aggregates values
+// using `m`.
+func NewAggregateAVLTreeFunc[K any, V any, A any](cmp func(a, b K) int, m Monoid[V, A]) *AggregateAVLTree[K, V, A] {
+	return &AggregateAVLTree[K, V, A]{
+		tree:    NewAVLTreeFunc[K, aggregateEntry[V, A]](cmp),
+		m:       &m,
+		augment: aggregateAugment[K](&m),
+	}
+}
+
+func (t *AggregateAVLTree[K, V, A]) newEntry(value V) aggregateEntry[V, A] {
+	return aggregateEntry[V, A]{
+		val: value,
+		agg: t.m.Measure(value),
+	}
+}
+
+// Insert inserts an entry. When the key already exists, this function returns a *KeyError wrapping ErrKeyExists.
+func (t *AggregateAVLTree[K, V, A]) Insert(key K, value V) error {
+	n, c := t.tree.find(key)
+	if n != nil && c == 0 {
+		return &KeyError{
+			Key: key,
+			Err: ErrKeyExists,
+		}
+	}
+	t.tree.root = n.insertChild(c, key, t.newEntry(value), t.augment)
+	return nil
+}
+
+// Put inserts an entry or overwrites the value of an existing entry, and returns the previous value.
+func (t *AggregateAVLTree[K, V, A]) Put(key K, value V) (old V, replaced bool) {
+	n, c := t.tree.find(key)
+	if n == nil || c != 0 {
+		t.tree.root = n.insertChild(c, key, t.newEntry(value), t.augment)
+		return old, false
+	}
+	old = n.val.val
+	n.val.val = value
+	// The shape doesn't change, so only the aggregates of the node and its ancestors need to be recomputed.
+	for ; n != nil; n = n.parent {
+		n.update(t.augment)
+	}
+	return old, true
+}
+
+// Delete deletes an entry and returns its value.
+func (t *AggregateAVLTree[K, V, A]) Delete(key K) (value V, found bool) {
+	n, c := t.tree.find(key)
+	if n == nil || c != 0 {
+		return
+	}
+	value = n.val.val
+	t.tree.root = n.remove(t.augment)
+	return value, true
+}
+
+// Search searches for an entry having a key that exactly matches a specified key and returns its value.
+func (t *AggregateAVLTree[K, V, A]) Search(key K) (value V, found bool) {
+	e, found := t.tree.Search(key)
+	return e.val, found
+}
+
+// Len returns the number of entries.
+func (t *AggregateAVLTree[K, V, A]) Len() int {
+	return t.tree.Len()
+}
+
+// All returns an iterator over entries in ascending order of keys. The tree must not be modified during the iteration.
+func (t *AggregateAVLTree[K, V, A]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, e := range t.tree.All() {
+			if !yield(k, e.val) {
+				return
+			}
+		}
+	}
+}
+
+// Total returns the aggregate of all values. When the tree is empty, this function returns the identity element.
+func (t *AggregateAVLTree[K, V, A]) Total() A {
+	return subtreeAggregate(t.m, t.tree.root)
+}
+
+// Aggregate returns the aggregate of values whose keys are between `lo` and `hi`, combined in ascending order of
+// keys. `opts` specifies whether each end is inclusive, exclusive, or unbounded in the same way as AVLTree.Range;
+// the order is ignored. When no key is in the range, this function returns the identity element.
+func (t *AggregateAVLTree[K, V, A]) Aggregate(lo, hi K, opts RangeOptions) A {
+	aboveLow, belowHigh := rangeBounds(t.tree.compare(), lo, hi, opts)
+
+	// Descend to the first node within the range. Keys in its left subtree are all below `hi`, and keys in its right
+	// subtree are all above `lo`, so each side needs only one more path.
+	n := t.tree.root
+	for n != nil {
+		switch {
+		case !aboveLow(n.split):
+			n = n.right
+		case !belowHigh(n.split):
+			n = n.left
+		default:
+			return t.m.Combine(
+				t.m.Combine(aggregateFrom(t.m, n.left, aboveLow), t.m.Measure(n.val.val)),
+				aggregateTo(t.m, n.right, belowHigh),
+			)
+		}
+	}
+	return t.m.Identity
+}
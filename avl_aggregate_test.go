@@ -0,0 +1,219 @@
+package forest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func testAggregateAVLStructure[K, V, A comparable](t *testing.T, tree *AggregateAVLTree[K, V, A]) {
+	t.Helper()
+
+	testAVLStructure(t, tree.tree)
+	var check func(n *avlNode[K, aggregateEntry[V, A]]) A
+	check = func(n *avlNode[K, aggregateEntry[V, A]]) A {
+		if n == nil {
+			return tree.m.Identity
+		}
+		agg := tree.m.Combine(tree.m.Combine(check(n.left), tree.m.Measure(n.val.val)), check(n.right))
+		if n.val.agg != agg {
+			t.Fatalf("node %v has an unexpected aggregate. want: %v, got: %v", n.split, agg, n.val.agg)
+		}
+		return agg
+	}
+	check(tree.tree.root)
+}
+
+// concatMonoid concatenates values in order. Because it isn't commutative, it detects aggregates combined in a wrong
+// order.
+var concatMonoid = Monoid[int, string]{
+	Identity: "",
+	Measure: func(v int) string {
+		return strconv.Itoa(v) + ","
+	},
+	Combine: func(a, b string) string {
+		return a + b
+	},
+}
+
+var sumMonoid = Monoid[int, int]{
+	Identity: 0,
+	Measure: func(v int) int {
+		return v
+	},
+	Combine: func(a, b int) int {
+		return a + b
+	},
+}
+
+func TestAggregateAVLTree_Aggregate(t *testing.T) {
+	tree := NewAggregateAVLTree[int, int, string](concatMonoid)
+	for _, k := range []int{40, 20, 60, 10, 30, 50, 70} {
+		err := tree.Insert(k, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	testAggregateAVLStructure(t, tree)
+
+	tests := []struct {
+		caption  string
+		lo       int
+		hi       int
+		opts     RangeOptions
+		expected string
+	}{
+		{
+			caption:  "[20, 50]",
+			lo:       20,
+			hi:       50,
+			expected: "20,30,40,50,",
+		},
+		{
+			caption: "(20, 50)",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				Low:  Exclusive,
+				High: Exclusive,
+			},
+			expected: "30,40,",
+		},
+		{
+			caption:  "[15, 35]",
+			lo:       15,
+			hi:       35,
+			expected: "20,30,",
+		},
+		{
+			caption: "(-inf, 30]",
+			hi:      30,
+			opts: RangeOptions{
+				Low: Unbounded,
+			},
+			expected: "10,20,30,",
+		},
+		{
+			caption: "[60, +inf)",
+			lo:      60,
+			opts: RangeOptions{
+				High: Unbounded,
+			},
+			expected: "60,70,",
+		},
+		{
+			caption: "(-inf, +inf)",
+			opts: RangeOptions{
+				Low:  Unbounded,
+				High: Unbounded,
+			},
+			expected: "10,20,30,40,50,60,70,",
+		},
+		{
+			caption:  "[41, 49]",
+			lo:       41,
+			hi:       49,
+			expected: "",
+		},
+		{
+			caption:  "[50, 20]",
+			lo:       50,
+			hi:       20,
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			agg := tree.Aggregate(tt.lo, tt.hi, tt.opts)
+			if agg != tt.expected {
+				t.Fatalf("unexpected aggregate. want: %v, got: %v", tt.expected, agg)
+			}
+		})
+	}
+
+	if total := tree.Total(); total != "10,20,30,40,50,60,70," {
+		t.Fatalf("unexpected total. want: %v, got: %v", "10,20,30,40,50,60,70,", total)
+	}
+}
+
+func TestAggregateAVLTree(t *testing.T) {
+	t.Run("Aggregates are maintained through insertion, overwriting, and deletion", func(t *testing.T) {
+		r := rand.New(rand.NewSource(5))
+		tree := NewAggregateAVLTree[int, int, int](sumMonoid)
+		entries := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(500)
+			switch r.Intn(3) {
+			case 0:
+				tree.Put(k, i)
+				entries[k] = i
+			case 1:
+				err := tree.Insert(k, i)
+				if _, exists := entries[k]; exists {
+					if !errors.Is(err, ErrKeyExists) {
+						t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries[k] = i
+			case 2:
+				v, ok := tree.Delete(k)
+				ev, eok := entries[k]
+				if ok != eok || v != ev {
+					t.Fatalf("unexpected result of Delete(%v). want: %v, %v, got: %v, %v", k, ev, eok, v, ok)
+				}
+				delete(entries, k)
+			}
+			if i%100 == 0 {
+				testAggregateAVLStructure(t, tree)
+			}
+		}
+		testAggregateAVLStructure(t, tree)
+		if tree.Len() != len(entries) {
+			t.Fatalf("unexpected length. want: %v, got: %v", len(entries), tree.Len())
+		}
+
+		for i := 0; i < 200; i++ {
+			lo, hi := r.Intn(520)-10, r.Intn(520)-10
+			opts := RangeOptions{
+				Low:  RangeBound(r.Intn(3)),
+				High: RangeBound(r.Intn(3)),
+			}
+			aboveLow, belowHigh := rangeBounds(compareOrdered[int], lo, hi, opts)
+			expected := 0
+			for k, v := range entries {
+				if aboveLow(k) && belowHigh(k) {
+					expected += v
+				}
+			}
+			if agg := tree.Aggregate(lo, hi, opts); agg != expected {
+				t.Fatalf("unexpected aggregate of %v, %v, %+v. want: %v, got: %v", lo, hi, opts, expected, agg)
+			}
+		}
+	})
+
+	t.Run("A tree with a comparison function aggregates values in the custom order", func(t *testing.T) {
+		tree := NewAggregateAVLTreeFunc[int, int, string](func(a, b int) int {
+			return b - a
+		}, concatMonoid)
+		for i := 1; i <= 5; i++ {
+			tree.Put(i, i)
+		}
+		testAggregateAVLStructure(t, tree)
+		if agg := tree.Aggregate(4, 2, RangeOptions{}); agg != "4,3,2," {
+			t.Fatalf("unexpected aggregate. want: %v, got: %v", "4,3,2,", agg)
+		}
+		var keys []string
+		for k, v := range tree.All() {
+			keys = append(keys, fmt.Sprintf("%v:%v", k, v))
+		}
+		if fmt.Sprint(keys) != "[5:5 4:4 3:3 2:2 1:1]" {
+			t.Fatalf("unexpected entries: %v", keys)
+		}
+	})
+}
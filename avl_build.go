@@ -0,0 +1,103 @@
+package forest
+
+import "golang.org/x/exp/constraints"
+
+// AVLTreeBuilder builds an AVL tree from entries given in ascending order of keys in linear time.
+type AVLTreeBuilder[K any, V any] struct {
+	cmp    func(a, b K) int
+	locate func(n *avlNode[K, V], key K) (*avlNode[K, V], int)
+	nodes  []*avlNode[K, V]
+}
+
+// NewAVLTreeBuilder returns a new builder of an AVL tree that can contain entries mapping `K` to `V`.
+func NewAVLTreeBuilder[K constraints.Ordered, V any]() *AVLTreeBuilder[K, V] {
+	return &AVLTreeBuilder[K, V]{
+		cmp:    compareOrdered[K],
+		locate: locateOrdered[K, V],
+	}
+}
+
+// NewAVLTreeBuilderFunc returns a new builder of an AVL tree that orders keys using `cmp`.
+func NewAVLTreeBuilderFunc[K any, V any](cmp func(a, b K) int) *AVLTreeBuilder[K, V] {
+	return &AVLTreeBuilder[K, V]{
+		cmp: cmp,
+	}
+}
+
+// Add appends an entry. The key must be greater than the key previously added. When the key equals the previous one,
+// this function returns a *KeyError wrapping ErrKeyExists, and when the key is less than the previous one, this
+// function returns a *KeyError wrapping ErrKeysNotSorted.
+func (b *AVLTreeBuilder[K, V]) Add(key K, value V) error {
+	if len(b.nodes) > 0 {
+		c := b.cmp(key, b.nodes[len(b.nodes)-1].split)
+		if c == 0 {
+			return &KeyError{
+				Key: key,
+				Err: ErrKeyExists,
+			}
+		}
+		if c < 0 {
+			return &KeyError{
+				Key: key,
+				Err: ErrKeysNotSorted,
+			}
+		}
+	}
+	b.nodes = append(b.nodes, newAVLNode(nil, key, value))
+	return nil
+}
+
+// Len returns the number of entries added so far.
+func (b *AVLTreeBuilder[K, V]) Len() int {
+	return len(b.nodes)
+}
+
+// Build returns a perfectly balanced tree containing the added entries and resets the builder.
+func (b *AVLTreeBuilder[K, V]) Build() *AVLTree[K, V] {
+	t := &AVLTree[K, V]{
+		root:   buildAVLNodes(b.nodes, nil),
+		cmp:    b.cmp,
+		locate: b.locate,
+	}
+	b.nodes = nil
+	return t
+}
+
+// buildAVLNodes links sorted nodes into a perfectly balanced subtree and returns its root.
+func buildAVLNodes[K any, V any](nodes []*avlNode[K, V], parent *avlNode[K, V]) *avlNode[K, V] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := nodes[mid]
+	n.parent = parent
+	n.left = buildAVLNodes(nodes[:mid], n)
+	n.right = buildAVLNodes(nodes[mid+1:], n)
+	n.update(nil)
+	return n
+}
+
+// NewAVLTreeFromSorted returns a new AVL tree containing entries mapping `keys[i]` to `values[i]` in linear time.
+// `keys` must be strictly ascending. See AVLTreeBuilder.Add for the errors this function returns.
+func NewAVLTreeFromSorted[K constraints.Ordered, V any](keys []K, values []V) (*AVLTree[K, V], error) {
+	return buildAVLTree(NewAVLTreeBuilder[K, V](), keys, values)
+}
+
+// NewAVLTreeFromSortedFunc is like NewAVLTreeFromSorted but orders keys using `cmp`.
+func NewAVLTreeFromSortedFunc[K any, V any](cmp func(a, b K) int, keys []K, values []V) (*AVLTree[K, V], error) {
+	return buildAVLTree(NewAVLTreeBuilderFunc[K, V](cmp), keys, values)
+}
+
+func buildAVLTree[K any, V any](b *AVLTreeBuilder[K, V], keys []K, values []V) (*AVLTree[K, V], error) {
+	if len(keys) != len(values) {
+		return nil, ErrLengthMismatch
+	}
+	b.nodes = make([]*avlNode[K, V], 0, len(keys))
+	for i, k := range keys {
+		err := b.Add(k, values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Build(), nil
+}
@@ -0,0 +1,125 @@
+package forest
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewAVLTreeFromSorted(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 7, 8, 100, 1023, 1024, 1025} {
+		keys := intRange(0, size)
+		values := make([]string, size)
+		for i, k := range keys {
+			values[i] = strconv.Itoa(k)
+		}
+		avl, err := NewAVLTreeFromSorted(keys, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAVLStructure(t, avl)
+		if avl.Len() != size {
+			t.Fatalf("unexpected length. want: %v, got: %v", size, avl.Len())
+		}
+		if k := collectAVLKeys(avl); !reflect.DeepEqual(k, keys) {
+			t.Fatalf("unexpected keys: %v", k)
+		}
+		for k, v := range avl.All() {
+			if v != strconv.Itoa(k) {
+				t.Fatalf("unexpected value of %v: %v", k, v)
+			}
+		}
+		if err := avl.Insert(size, ""); err != nil {
+			t.Fatal(err)
+		}
+		testAVLStructure(t, avl)
+	}
+
+	t.Run("Duplicate keys are rejected", func(t *testing.T) {
+		_, err := NewAVLTreeFromSorted([]int{1, 2, 2, 3}, []int{1, 2, 3, 4})
+		if !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != 2 {
+			t.Fatalf("an error must report the duplicated key: %v", err)
+		}
+	})
+
+	t.Run("Unsorted keys are rejected", func(t *testing.T) {
+		_, err := NewAVLTreeFromSorted([]int{1, 3, 2}, []int{1, 2, 3})
+		if !errors.Is(err, ErrKeysNotSorted) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeysNotSorted, err)
+		}
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != 2 {
+			t.Fatalf("an error must report the out-of-order key: %v", err)
+		}
+	})
+
+	t.Run("Keys and values must have the same length", func(t *testing.T) {
+		_, err := NewAVLTreeFromSorted([]int{1, 2}, []int{1})
+		if !errors.Is(err, ErrLengthMismatch) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrLengthMismatch, err)
+		}
+	})
+
+	t.Run("Keys can be ordered by a comparison function", func(t *testing.T) {
+		keys := []string{"b", "A", "c"}
+		_, err := NewAVLTreeFromSortedFunc(strings.Compare, keys, []int{1, 2, 3})
+		if !errors.Is(err, ErrKeysNotSorted) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeysNotSorted, err)
+		}
+		cmp := func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		}
+		avl, err := NewAVLTreeFromSortedFunc(cmp, []string{"A", "b", "C"}, []int{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := avl.Search("a"); !ok || v != 1 {
+			t.Fatalf("unexpected result. want: 1, true, got: %v, %v", v, ok)
+		}
+	})
+}
+
+func TestAVLTreeBuilder(t *testing.T) {
+	b := NewAVLTreeBuilder[int, int]()
+	for _, k := range intRange(0, 10) {
+		if err := b.Add(k, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Add(5, 5); !errors.Is(err, ErrKeysNotSorted) {
+		t.Fatalf("unexpected error. want: %v, got: %v", ErrKeysNotSorted, err)
+	}
+	if b.Len() != 10 {
+		t.Fatalf("unexpected length. want: 10, got: %v", b.Len())
+	}
+	avl := b.Build()
+	testAVLStructure(t, avl)
+	if avl.Len() != 10 {
+		t.Fatalf("unexpected length. want: 10, got: %v", avl.Len())
+	}
+	if b.Len() != 0 {
+		t.Fatalf("a builder must be reset. length: %v", b.Len())
+	}
+	if err := b.Add(0, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkNewAVLTreeFromSorted(b *testing.B) {
+	for _, size := range benchmarkAVLTreeSizes() {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			keys := intRange(0, size)
+			for i := 0; i < b.N; i++ {
+				if _, err := NewAVLTreeFromSorted(keys, keys); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package forest
+
+// AVLTreeCursor points to an entry of an AVL tree and moves forward and backward in the order of keys. A cursor
+// becomes invalid when it moves past either end of the tree, and it becomes valid again by seeking.
+//
+// The tree must not be modified except through the cursor while the cursor is in use. Deletion moves keys between
+// nodes, so a cursor may point to a wrong entry after the tree is modified by other means.
+type AVLTreeCursor[K any, V any] struct {
+	tree *AVLTree[K, V]
+	node *avlNode[K, V]
+}
+
+// Cursor returns a new cursor of the tree. The cursor is invalid until it seeks an entry.
+func (t *AVLTree[K, V]) Cursor() *AVLTreeCursor[K, V] {
+	return &AVLTreeCursor[K, V]{
+		tree: t,
+	}
+}
+
+// Valid reports whether the cursor points to an entry.
+func (c *AVLTreeCursor[K, V]) Valid() bool {
+	return c.node != nil
+}
+
+// Seek moves the cursor to an entry having the smallest key that is greater than or equal to a specified key.
+// This function reports whether such an entry exists.
+func (c *AVLTreeCursor[K, V]) Seek(key K) bool {
+	c.node = c.tree.root.ceiling(c.tree.compare(), key, true)
+	return c.node != nil
+}
+
+// SeekFirst moves the cursor to an entry having the minimum key. This function reports whether the tree has entries.
+func (c *AVLTreeCursor[K, V]) SeekFirst() bool {
+	c.node = nil
+	if c.tree.root != nil {
+		c.node = c.tree.root.leftmost()
+	}
+	return c.node != nil
+}
+
+// SeekLast moves the cursor to an entry having the maximum key. This function reports whether the tree has entries.
+func (c *AVLTreeCursor[K, V]) SeekLast() bool {
+	c.node = nil
+	if c.tree.root != nil {
+		c.node = c.tree.root.rightmost()
+	}
+	return c.node != nil
+}
+
+// Next moves the cursor to an entry having the next larger key and reports whether the cursor is still valid.
+func (c *AVLTreeCursor[K, V]) Next() bool {
+	if c.node != nil {
+		c.node = c.node.successor()
+	}
+	return c.node != nil
+}
+
+// Prev moves the cursor to an entry having the next smaller key and reports whether the cursor is still valid.
+func (c *AVLTreeCursor[K, V]) Prev() bool {
+	if c.node != nil {
+		c.node = c.node.predecessor()
+	}
+	return c.node != nil
+}
+
+// Key returns the key of the current entry. The cursor must be valid.
+func (c *AVLTreeCursor[K, V]) Key() K {
+	return c.node.split
+}
+
+// Value returns the value of the current entry. The cursor must be valid.
+func (c *AVLTreeCursor[K, V]) Value() V {
+	return c.node.val
+}
+
+// SetValue overwrites the value of the current entry in place. The cursor must be valid.
+func (c *AVLTreeCursor[K, V]) SetValue(value V) {
+	c.node.val = value
+}
+
+// Delete deletes the current entry and moves the cursor to an entry having the next larger key. When the deleted
+// entry had the maximum key, the cursor becomes invalid. This function reports whether an entry was deleted.
+func (c *AVLTreeCursor[K, V]) Delete() bool {
+	if c.node == nil {
+		return false
+	}
+	// The successor keeps its entry because remove moves only the entry of the predecessor, so the cursor can follow
+	// the parent pointers without searching the tree again.
+	next := c.node.successor()
+	c.tree.root = c.node.remove(nil)
+	c.node = next
+	return true
+}
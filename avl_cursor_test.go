@@ -0,0 +1,153 @@
+package forest
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestAVLTreeCursor(t *testing.T) {
+	newTree := func() *AVLTree[int, string] {
+		avl := NewAVLTree[int, string]()
+		for _, k := range []int{40, 20, 60, 10, 30, 50, 70} {
+			if err := avl.Insert(k, strconv.Itoa(k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return avl
+	}
+
+	t.Run("A cursor moves forward and backward", func(t *testing.T) {
+		c := newTree().Cursor()
+		if c.Valid() {
+			t.Fatal("a new cursor must be invalid")
+		}
+
+		var keys []int
+		for ok := c.SeekFirst(); ok; ok = c.Next() {
+			keys = append(keys, c.Key())
+		}
+		if !reflect.DeepEqual(keys, []int{10, 20, 30, 40, 50, 60, 70}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+		if c.Valid() {
+			t.Fatal("a cursor moved past the end must be invalid")
+		}
+
+		keys = nil
+		for ok := c.SeekLast(); ok; ok = c.Prev() {
+			keys = append(keys, c.Key())
+		}
+		if !reflect.DeepEqual(keys, []int{70, 60, 50, 40, 30, 20, 10}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+
+		if !c.Seek(35) || c.Key() != 40 || c.Value() != "40" {
+			t.Fatal("Seek must move the cursor to the ceiling of a key")
+		}
+		if !c.Prev() || c.Key() != 30 {
+			t.Fatal("Prev must move the cursor to the previous entry")
+		}
+		if !c.Next() || !c.Next() || c.Key() != 50 {
+			t.Fatal("Next must move the cursor to the next entry")
+		}
+		if !c.Seek(40) || c.Key() != 40 {
+			t.Fatal("Seek must move the cursor to an exact match")
+		}
+		if c.Seek(71) {
+			t.Fatal("Seek must fail when no key is greater than or equal to a specified key")
+		}
+	})
+
+	t.Run("A cursor can edit values in place", func(t *testing.T) {
+		avl := newTree()
+		c := avl.Cursor()
+		for ok := c.Seek(30); ok && c.Key() <= 50; ok = c.Next() {
+			c.SetValue(c.Value() + "!")
+		}
+		for k, v := range avl.All() {
+			expected := strconv.Itoa(k)
+			if k >= 30 && k <= 50 {
+				expected += "!"
+			}
+			if v != expected {
+				t.Fatalf("unexpected value of %v. want: %v, got: %v", k, expected, v)
+			}
+		}
+	})
+
+	t.Run("A cursor remains valid after deleting entries", func(t *testing.T) {
+		avl := newTree()
+		c := avl.Cursor()
+		for ok := c.SeekFirst(); ok; {
+			if c.Key()%20 == 0 {
+				if !c.Delete() {
+					t.Fatal("Delete must succeed")
+				}
+				ok = c.Valid()
+				continue
+			}
+			ok = c.Next()
+		}
+		testAVLStructure(t, avl)
+		if keys := collectAVLKeys(avl); !reflect.DeepEqual(keys, []int{10, 30, 50, 70}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+
+		if !c.SeekLast() || !c.Delete() || c.Valid() {
+			t.Fatal("deleting the last entry must invalidate the cursor")
+		}
+		if c.Delete() {
+			t.Fatal("an invalid cursor must not delete an entry")
+		}
+		for c.SeekFirst() {
+			c.Delete()
+		}
+		if avl.Len() != 0 {
+			t.Fatalf("unexpected length. want: 0, got: %v", avl.Len())
+		}
+	})
+
+	t.Run("Deleting through a cursor follows parent pointers without comparing keys", func(t *testing.T) {
+		calls := 0
+		avl := NewAVLTreeFunc[int, int](func(a, b int) int {
+			calls++
+			return compareOrdered(a, b)
+		})
+		r := rand.New(rand.NewSource(7))
+		for _, k := range r.Perm(500) {
+			avl.Put(k, k)
+		}
+		c := avl.Cursor()
+		calls = 0
+		var kept []int
+		for ok := c.SeekFirst(); ok; {
+			k := c.Key()
+			if r.Intn(3) == 0 {
+				kept = append(kept, k)
+				ok = c.Next()
+				continue
+			}
+			c.Delete()
+			if c.Valid() && c.Key() != k+1 {
+				t.Fatalf("the cursor must move to the next key. want: %v, got: %v", k+1, c.Key())
+			}
+			ok = c.Valid()
+		}
+		if calls != 0 {
+			t.Fatalf("keys must not be compared. comparisons: %v", calls)
+		}
+		testAVLStructure(t, avl)
+		if keys := collectAVLKeys(avl); !reflect.DeepEqual(keys, kept) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", kept, keys)
+		}
+	})
+
+	t.Run("A cursor of an empty tree is invalid", func(t *testing.T) {
+		c := NewAVLTree[int, int]().Cursor()
+		if c.SeekFirst() || c.SeekLast() || c.Seek(0) || c.Next() || c.Prev() {
+			t.Fatal("a cursor of an empty tree must be invalid")
+		}
+	})
+}
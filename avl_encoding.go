@@ -0,0 +1,152 @@
+package forest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// avlTreeEncodingVersion is the version of the binary and JSON encodings of AVLTree. Increment it when changing
+// either encoding.
+const avlTreeEncodingVersion = 1
+
+// avlTreeBinaryMagic is the first bytes of the binary encoding of AVLTree.
+var avlTreeBinaryMagic = []byte("AVLT")
+
+var errNoComparator = errors.New("keys of the tree have no natural order; create the tree using NewAVLTreeFunc")
+
+// newDecodingBuilder returns a builder that orders keys in the same way as the tree. The zero value orders keys in
+// their natural order, and this function returns errNoComparator when `K` has none.
+func (t *AVLTree[K, V]) newDecodingBuilder() (*AVLTreeBuilder[K, V], error) {
+	cmp := t.cmp
+	if cmp == nil {
+		cmp, _ = naturalOrder[K, V]()
+		if cmp == nil {
+			return nil, errNoComparator
+		}
+	}
+	return NewAVLTreeBuilderFunc[K, V](cmp), nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding consists of a header (magic bytes, a version, and
+// the number of entries) followed by keys and values encoded with encoding/gob in ascending order of keys, so it
+// doesn't depend on the shape of the tree. It has a value receiver so that a tree stored by value, such as a field of
+// a struct passed to json.Marshal by value, is encoded too.
+func (t AVLTree[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(avlTreeBinaryMagic)
+	buf.WriteByte(avlTreeEncodingVersion)
+	buf.Write(binary.AppendUvarint(nil, uint64(t.Len())))
+	enc := gob.NewEncoder(&buf)
+	for k, v := range t.All() {
+		err := enc.Encode(&k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode a key %v: %w", k, err)
+		}
+		err = enc.Encode(&v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode a value of a key %v: %w", k, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the entries of the tree with decoded ones and
+// rebuilds the tree in linear time. The zero value can decode entries when `K` is an ordered type (see AVLTree); for
+// other key types, create the tree using NewAVLTreeFunc beforehand so that it knows how to order keys.
+func (t *AVLTree[K, V]) UnmarshalBinary(data []byte) error {
+	b, err := t.newDecodingBuilder()
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, avlTreeBinaryMagic) {
+		return fmt.Errorf("invalid AVL tree encoding: missing magic bytes")
+	}
+	data = data[len(avlTreeBinaryMagic):]
+	if len(data) == 0 {
+		return fmt.Errorf("invalid AVL tree encoding: missing version")
+	}
+	if data[0] != avlTreeEncodingVersion {
+		return fmt.Errorf("unsupported AVL tree encoding version: %v", data[0])
+	}
+	data = data[1:]
+	count, l := binary.Uvarint(data)
+	if l <= 0 {
+		return fmt.Errorf("invalid AVL tree encoding: malformed number of entries")
+	}
+	dec := gob.NewDecoder(bytes.NewReader(data[l:]))
+	for i := uint64(0); i < count; i++ {
+		var k K
+		var v V
+		err := dec.Decode(&k)
+		if err != nil {
+			return fmt.Errorf("failed to decode a key: %w", err)
+		}
+		err = dec.Decode(&v)
+		if err != nil {
+			return fmt.Errorf("failed to decode a value of a key %v: %w", k, err)
+		}
+		err = b.Add(k, v)
+		if err != nil {
+			return err
+		}
+	}
+	t.root = b.Build().root
+	return nil
+}
+
+type avlTreeJSON[K any, V any] struct {
+	Version int                      `json:"version"`
+	Entries []avlTreeJSONEntry[K, V] `json:"entries"`
+}
+
+type avlTreeJSONEntry[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// MarshalJSON implements json.Marshaler. The encoding is an object having a version and entries in ascending order of
+// keys, like `{"version":1,"entries":[{"key":1,"value":"a"}]}`. Like MarshalBinary, it has a value receiver.
+func (t AVLTree[K, V]) MarshalJSON() ([]byte, error) {
+	j := avlTreeJSON[K, V]{
+		Version: avlTreeEncodingVersion,
+		Entries: make([]avlTreeJSONEntry[K, V], 0, t.Len()),
+	}
+	for k, v := range t.All() {
+		j.Entries = append(j.Entries, avlTreeJSONEntry[K, V]{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the entries of the tree with decoded ones and rebuilds the
+// tree in linear time. The entries must be in ascending order of keys. Like UnmarshalBinary, the zero value can decode
+// entries only when `K` is an ordered type, so a tree can be a field of a struct passed to json.Unmarshal.
+func (t *AVLTree[K, V]) UnmarshalJSON(data []byte) error {
+	b, err := t.newDecodingBuilder()
+	if err != nil {
+		return err
+	}
+	var j avlTreeJSON[K, V]
+	err = json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	if j.Version != avlTreeEncodingVersion {
+		return fmt.Errorf("unsupported AVL tree encoding version: %v", j.Version)
+	}
+	b.nodes = make([]*avlNode[K, V], 0, len(j.Entries))
+	for _, e := range j.Entries {
+		err := b.Add(e.Key, e.Value)
+		if err != nil {
+			return err
+		}
+	}
+	t.root = b.Build().root
+	return nil
+}
@@ -0,0 +1,281 @@
+package forest
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &AVLTree[int, int]{}
+	_ encoding.BinaryUnmarshaler = &AVLTree[int, int]{}
+	_ json.Marshaler             = &AVLTree[int, int]{}
+	_ json.Unmarshaler           = &AVLTree[int, int]{}
+	_ encoding.BinaryMarshaler   = AVLTree[int, int]{}
+	_ json.Marshaler             = AVLTree[int, int]{}
+)
+
+type encodingTestValue struct {
+	Name  string
+	Score float64
+}
+
+func newEncodingTestTree(t *testing.T, size int) *AVLTree[int, encodingTestValue] {
+	t.Helper()
+	avl := NewAVLTree[int, encodingTestValue]()
+	for _, k := range intRange(0, size) {
+		if err := avl.Insert(k*3-size, encodingTestValue{Name: strconv.Itoa(k), Score: float64(k) / 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return avl
+}
+
+func testAVLTreeEntriesEqual[K, V any](t *testing.T, actual, expected *AVLTree[K, V]) {
+	t.Helper()
+
+	testAVLStructure(t, actual)
+	if actual.Len() != expected.Len() {
+		t.Fatalf("unexpected length. want: %v, got: %v", expected.Len(), actual.Len())
+	}
+	var ak, ek []K
+	var av, ev []V
+	for k, v := range actual.All() {
+		ak = append(ak, k)
+		av = append(av, v)
+	}
+	for k, v := range expected.All() {
+		ek = append(ek, k)
+		ev = append(ev, v)
+	}
+	if !reflect.DeepEqual(ak, ek) || !reflect.DeepEqual(av, ev) {
+		t.Fatalf("unexpected entries. want: %v, %v, got: %v, %v", ek, ev, ak, av)
+	}
+}
+
+func TestAVLTree_BinaryEncoding(t *testing.T) {
+	for _, size := range []int{0, 1, 10, 1000} {
+		avl := newEncodingTestTree(t, size)
+		data, err := avl.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := NewAVLTree[int, encodingTestValue]()
+		if err := decoded.Insert(-1000000, encodingTestValue{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, decoded, avl)
+	}
+
+	t.Run("Malformed data are rejected", func(t *testing.T) {
+		data, err := newEncodingTestTree(t, 10).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsupported := append([]byte{}, data...)
+		unsupported[len(avlTreeBinaryMagic)] = avlTreeEncodingVersion + 1
+		for _, d := range [][]byte{nil, []byte("XXXX"), data[:len(avlTreeBinaryMagic)], unsupported, data[:len(data)-1]} {
+			if err := NewAVLTree[int, encodingTestValue]().UnmarshalBinary(d); err == nil {
+				t.Fatalf("an error must occur: %v", d)
+			}
+		}
+	})
+
+	t.Run("The zero value decodes keys of a predeclared ordered type", func(t *testing.T) {
+		src := newEncodingTestTree(t, 100)
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var avl AVLTree[int, encodingTestValue]
+		if err := avl.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, &avl, src)
+		if err := avl.Insert(-1000000, encodingTestValue{}); err != nil {
+			t.Fatal(err)
+		}
+		testAVLStructure(t, &avl)
+	})
+
+	t.Run("The zero value decodes keys of a named ordered type", func(t *testing.T) {
+		src := NewAVLTree[time.Duration, string]()
+		src.Put(time.Second, "second")
+		src.Put(time.Millisecond, "millisecond")
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var avl AVLTree[time.Duration, string]
+		if err := avl.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, &avl, src)
+	})
+
+	t.Run("The zero value cannot decode keys that aren't ordered", func(t *testing.T) {
+		type point struct {
+			X, Y int
+		}
+		cmp := func(a, b point) int {
+			if c := compareOrdered(a.X, b.X); c != 0 {
+				return c
+			}
+			return compareOrdered(a.Y, b.Y)
+		}
+		src := NewAVLTreeFunc[point, string](cmp)
+		src.Put(point{X: 1}, "one")
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var avl AVLTree[point, string]
+		if err := avl.UnmarshalBinary(data); !errors.Is(err, errNoComparator) {
+			t.Fatalf("unexpected error. want: %v, got: %v", errNoComparator, err)
+		}
+		if err := json.Unmarshal([]byte(`{"version":1,"entries":[]}`), &avl); !errors.Is(err, errNoComparator) {
+			t.Fatalf("unexpected error. want: %v, got: %v", errNoComparator, err)
+		}
+		dst := NewAVLTreeFunc[point, string](cmp)
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, dst, src)
+	})
+}
+
+func TestAVLTree_JSONEncoding(t *testing.T) {
+	t.Run("Entries are encoded in ascending order of keys", func(t *testing.T) {
+		avl := NewAVLTree[string, int]()
+		for i, k := range []string{"b", "c", "a"} {
+			if err := avl.Insert(k, i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		data, err := json.Marshal(avl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"version":1,"entries":[{"key":"a","value":2},{"key":"b","value":0},{"key":"c","value":1}]}`
+		if string(data) != expected {
+			t.Fatalf("unexpected JSON. want: %v, got: %v", expected, string(data))
+		}
+	})
+
+	t.Run("A decoded tree has the same entries", func(t *testing.T) {
+		for _, size := range []int{0, 1, 10, 1000} {
+			avl := newEncodingTestTree(t, size)
+			data, err := json.Marshal(avl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := NewAVLTree[int, encodingTestValue]()
+			if err := json.Unmarshal(data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			testAVLTreeEntriesEqual(t, decoded, avl)
+		}
+	})
+
+	t.Run("A tree can be a field of a struct", func(t *testing.T) {
+		type index struct {
+			Tree *AVLTree[int, string] `json:"tree"`
+		}
+		src := index{
+			Tree: NewAVLTree[int, string](),
+		}
+		src.Tree.Put(1, "one")
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := index{
+			Tree: NewAVLTree[int, string](),
+		}
+		if err := json.Unmarshal(data, &dst); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, dst.Tree, src.Tree)
+	})
+
+	t.Run("A zero-value field of a struct can be decoded", func(t *testing.T) {
+		type index struct {
+			Tree    AVLTree[int, string]  `json:"tree"`
+			TreePtr *AVLTree[int, string] `json:"tree_ptr"`
+		}
+		src := index{
+			TreePtr: NewAVLTree[int, string](),
+		}
+		src.Tree.Put(2, "two")
+		src.Tree.Put(1, "one")
+		src.TreePtr.Put(3, "three")
+		data, err := json.Marshal(&src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dst index
+		if err := json.Unmarshal(data, &dst); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, &dst.Tree, &src.Tree)
+		testAVLTreeEntriesEqual(t, dst.TreePtr, src.TreePtr)
+	})
+
+	t.Run("A field of a struct marshaled by value round-trips", func(t *testing.T) {
+		type index struct {
+			Tree AVLTree[string, int]
+		}
+		var src index
+		src.Tree.Put("b", 2)
+		src.Tree.Put("a", 1)
+		data, err := json.Marshal(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `{"Tree":{"version":1,"entries":[{"key":"a","value":1},{"key":"b","value":2}]}}`; string(data) != expected {
+			t.Fatalf("unexpected encoding. want: %v, got: %v", expected, string(data))
+		}
+		var dst index
+		if err := json.Unmarshal(data, &dst); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, &dst.Tree, &src.Tree)
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+			t.Fatal(err)
+		}
+		var gobDst index
+		if err := gob.NewDecoder(&buf).Decode(&gobDst); err != nil {
+			t.Fatal(err)
+		}
+		testAVLTreeEntriesEqual(t, &gobDst.Tree, &src.Tree)
+	})
+
+	t.Run("Unsorted or duplicated entries are rejected", func(t *testing.T) {
+		err := json.Unmarshal([]byte(`{"version":1,"entries":[{"key":2,"value":""},{"key":1,"value":""}]}`), NewAVLTree[int, string]())
+		if !errors.Is(err, ErrKeysNotSorted) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeysNotSorted, err)
+		}
+		err = json.Unmarshal([]byte(`{"version":1,"entries":[{"key":1,"value":""},{"key":1,"value":""}]}`), NewAVLTree[int, string]())
+		if !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+	})
+
+	t.Run("An unsupported version is rejected", func(t *testing.T) {
+		err := json.Unmarshal([]byte(`{"version":2,"entries":[]}`), NewAVLTree[int, string]())
+		if err == nil {
+			t.Fatal("an error must occur")
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package forest
+
+import "fmt"
+
+func ExampleAVLTree() {
+	avl := NewAVLTree[int, string]()
+	for _, k := range []int{30, 10, 50, 20, 40} {
+		err := avl.Insert(k, fmt.Sprintf("v%v", k))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	fmt.Println("Ascending order:")
+	for k, v := range avl.All() {
+		fmt.Println(k, v)
+	}
+
+	fmt.Println("Descending order:")
+	for k, v := range avl.Backward() {
+		fmt.Println(k, v)
+	}
+
+	// Output:
+	// Ascending order:
+	// 10 v10
+	// 20 v20
+	// 30 v30
+	// 40 v40
+	// 50 v50
+	// Descending order:
+	// 50 v50
+	// 40 v40
+	// 30 v30
+	// 20 v20
+	// 10 v10
+}
+
+func ExampleAggregateAVLTree() {
+	// Sum up bytes transferred per second.
+	tree := NewAggregateAVLTree[int, int, int](Monoid[int, int]{
+		Identity: 0,
+		Measure: func(bytes int) int {
+			return bytes
+		},
+		Combine: func(a, b int) int {
+			return a + b
+		},
+	})
+	tree.Put(100, 512)
+	tree.Put(101, 1024)
+	tree.Put(105, 256)
+	tree.Put(110, 2048)
+
+	fmt.Println(tree.Aggregate(100, 110, RangeOptions{High: Exclusive}))
+	// Output:
+	// 1792
+}
@@ -0,0 +1,126 @@
+package forest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// WriteDOT writes the structure of the tree in the Graphviz DOT language. Each node is labeled with its key, height,
+// and balance factor.
+func (t *AVLTree[K, V]) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph AVLTree {\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	if t.root != nil {
+		id := 0
+		t.root.writeDOT(&b, &id)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeDOT writes a subtree in pre-order and returns the ID of the node. `id` holds the next unused ID.
+// When a node has only one child, an invisible node takes the place of the missing child to keep the layout.
+func (n *avlNode[K, V]) writeDOT(b *strings.Builder, id *int) int {
+	self := *id
+	*id++
+	label := fmt.Sprintf("%v\nh=%v bf=%v", n.split, n.height, n.balanceFactor())
+	fmt.Fprintf(b, "\tn%v [label=\"%v\"];\n", self, escapeDOT(label))
+	if n.left == nil && n.right == nil {
+		return self
+	}
+	for _, c := range []*avlNode[K, V]{n.left, n.right} {
+		if c == nil {
+			fmt.Fprintf(b, "\tn%v [label=\"\", style=invis];\n", *id)
+			fmt.Fprintf(b, "\tn%v -> n%v [style=invis];\n", self, *id)
+			*id++
+			continue
+		}
+		child := c.writeDOT(b, id)
+		fmt.Fprintf(b, "\tn%v -> n%v;\n", self, child)
+	}
+	return self
+}
+
+// escapeDOT escapes a string so that it can be embedded in a double-quoted DOT string.
+func escapeDOT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// String renders the keys of the tree as ASCII art like the following.
+//
+//	  8__
+//	 /   \
+//	 7  10_
+//	/  /   \
+//	6  9  11
+func (t *AVLTree[K, V]) String() string {
+	if t.root == nil {
+		return ""
+	}
+	lines, _, _ := t.root.render()
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// render returns lines rendering a subtree, the width of the lines, and the horizontal position of the root.
+func (n *avlNode[K, V]) render() (lines []string, width int, middle int) {
+	label := fmt.Sprint(n.split)
+	u := utf8.RuneCountInString(label)
+	switch {
+	case n.left == nil && n.right == nil:
+		return []string{label}, u, u / 2
+	case n.right == nil:
+		ls, w, m := n.left.render()
+		lines = []string{
+			spaces(m+1) + underscores(w-m-1) + label,
+			spaces(m) + "/" + spaces(w-m-1+u),
+		}
+		for _, l := range ls {
+			lines = append(lines, l+spaces(u))
+		}
+		return lines, w + u, w + u/2
+	case n.left == nil:
+		rs, w, m := n.right.render()
+		lines = []string{
+			label + underscores(m) + spaces(w-m),
+			spaces(u+m) + "\\" + spaces(w-m-1),
+		}
+		for _, l := range rs {
+			lines = append(lines, spaces(u)+l)
+		}
+		return lines, w + u, u / 2
+	default:
+		ls, lw, lm := n.left.render()
+		rs, rw, rm := n.right.render()
+		lines = []string{
+			spaces(lm+1) + underscores(lw-lm-1) + label + underscores(rm) + spaces(rw-rm),
+			spaces(lm) + "/" + spaces(lw-lm-1+u+rm) + "\\" + spaces(rw-rm-1),
+		}
+		for i := 0; i < len(ls) || i < len(rs); i++ {
+			l := spaces(lw)
+			if i < len(ls) {
+				l = ls[i]
+			}
+			r := spaces(rw)
+			if i < len(rs) {
+				r = rs[i]
+			}
+			lines = append(lines, l+spaces(u)+r)
+		}
+		return lines, lw + u + rw, lw + u/2
+	}
+}
+
+func spaces(n int) string {
+	return strings.Repeat(" ", n)
+}
+
+func underscores(n int) string {
+	return strings.Repeat("_", n)
+}
@@ -0,0 +1,117 @@
+package forest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAVLTree_String(t *testing.T) {
+	tests := []struct {
+		caption  string
+		keys     []string
+		expected string
+	}{
+		{
+			caption:  "an empty tree",
+			keys:     nil,
+			expected: "",
+		},
+		{
+			caption:  "a single node",
+			keys:     []string{"a"},
+			expected: "a",
+		},
+		{
+			caption: "nodes having a left child",
+			keys:    []string{"cat", "ant", "dog", "bee"},
+			expected: strings.Join([]string{
+				"  ____cat_",
+				" /        \\",
+				"ant_     dog",
+				"    \\",
+				"   bee",
+			}, "\n"),
+		},
+		{
+			caption: "a complete tree",
+			keys:    []string{"b", "a", "c"},
+			expected: strings.Join([]string{
+				" b",
+				"/ \\",
+				"a c",
+			}, "\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			avl := NewAVLTree[string, int]()
+			for _, k := range tt.keys {
+				if err := avl.Insert(k, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if s := avl.String(); s != tt.expected {
+				t.Fatalf("unexpected rendering. want:\n%v\ngot:\n%v", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestAVLTree_WriteDOT(t *testing.T) {
+	t.Run("Nodes are labeled with keys, heights, and balance factors", func(t *testing.T) {
+		avl := NewAVLTree[string, int]()
+		for _, k := range []string{"b", "a", "c", `"d"`} {
+			if err := avl.Insert(k, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var b strings.Builder
+		if err := avl.WriteDOT(&b); err != nil {
+			t.Fatal(err)
+		}
+		expected := `digraph AVLTree {
+	node [shape=circle];
+	n0 [label="b\nh=3 bf=-1"];
+	n1 [label="a\nh=2 bf=-1"];
+	n2 [label="\"d\"\nh=1 bf=0"];
+	n1 -> n2;
+	n3 [label="", style=invis];
+	n1 -> n3 [style=invis];
+	n0 -> n1;
+	n4 [label="c\nh=1 bf=0"];
+	n0 -> n4;
+}
+`
+		if b.String() != expected {
+			t.Fatalf("unexpected DOT. want:\n%v\ngot:\n%v", expected, b.String())
+		}
+	})
+
+	t.Run("An empty tree is written as an empty graph", func(t *testing.T) {
+		var b strings.Builder
+		if err := NewAVLTree[int, int]().WriteDOT(&b); err != nil {
+			t.Fatal(err)
+		}
+		expected := "digraph AVLTree {\n\tnode [shape=circle];\n}\n"
+		if b.String() != expected {
+			t.Fatalf("unexpected DOT. want:\n%v\ngot:\n%v", expected, b.String())
+		}
+	})
+
+	t.Run("A write error is returned", func(t *testing.T) {
+		err := NewAVLTree[int, int]().WriteDOT(failingWriter{})
+		if !errors.Is(err, errWriteFailed) {
+			t.Fatalf("unexpected error. want: %v, got: %v", errWriteFailed, err)
+		}
+	})
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
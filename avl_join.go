@@ -0,0 +1,264 @@
+package forest
+
+// attach makes `l` and `r` children of a node and updates the height and the size of the node.
+func (n *avlNode[K, V]) attach(l, r *avlNode[K, V]) {
+	n.left = l
+	n.right = r
+	if l != nil {
+		l.parent = n
+	}
+	if r != nil {
+		r.parent = n
+	}
+	n.update(nil)
+}
+
+// detach cuts a node off from its parent and children and returns the children as roots of independent subtrees.
+func (n *avlNode[K, V]) detach() (l, r *avlNode[K, V]) {
+	l, r = n.left, n.right
+	if l != nil {
+		l.parent = nil
+	}
+	if r != nil {
+		r.parent = nil
+	}
+	n.parent = nil
+	n.left = nil
+	n.right = nil
+	return l, r
+}
+
+// joinAVLNodes concatenates two subtrees using a node `k` as a separator and returns a new root.
+// All keys in `l` must be less than the key of `k`, and all keys in `r` must be greater than it.
+// `l` and `r` must be roots (or nil), and `k` must be detached.
+// This function descends only the spine of the taller subtree, so it runs in O(|height(l) - height(r)| + 1) time.
+func joinAVLNodes[K any, V any](l, k, r *avlNode[K, V]) *avlNode[K, V] {
+	hl, hr := l.subtreeHeight(), r.subtreeHeight()
+	switch {
+	case hl > hr+1:
+		// Descend the right spine of `l` to a subtree that is as tall as `r` and replace it with `k`.
+		p := l
+		for p.right.subtreeHeight() > hr+1 {
+			p = p.right
+		}
+		k.attach(p.right, r)
+		k.parent = p
+		p.right = k
+		return p.rebalanceUpward(nil)
+	case hr > hl+1:
+		// Descend the left spine of `r` to a subtree that is as tall as `l` and replace it with `k`.
+		p := r
+		for p.left.subtreeHeight() > hl+1 {
+			p = p.left
+		}
+		k.attach(l, p.left)
+		k.parent = p
+		p.left = k
+		return p.rebalanceUpward(nil)
+	default:
+		k.attach(l, r)
+		k.parent = nil
+		return k
+	}
+}
+
+// concatAVLNodes concatenates two subtrees without a separator and returns a new root.
+// All keys in `l` must be less than all keys in `r`, and `l` and `r` must be roots (or nil).
+func concatAVLNodes[K any, V any](l, r *avlNode[K, V]) *avlNode[K, V] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	k := r.leftmost()
+	r = k.remove(nil)
+	return joinAVLNodes(l, k, r)
+}
+
+// splitAVLNode splits a subtree into a subtree having keys less than `key`, a node having `key`, and a subtree having
+// keys greater than `key`. When `key` doesn't exist, `m` is nil. The returned nodes are detached from each other.
+// Nodes of the original subtree are reused, so the original subtree must not be used after the split.
+func splitAVLNode[K any, V any](cmp func(a, b K) int, n *avlNode[K, V], key K) (l, m, r *avlNode[K, V]) {
+	if n == nil {
+		return nil, nil, nil
+	}
+	left, right := n.detach()
+	switch c := cmp(key, n.split); {
+	case c < 0:
+		l, m, r = splitAVLNode(cmp, left, key)
+		return l, m, joinAVLNodes(r, n, right)
+	case c > 0:
+		l, m, r = splitAVLNode(cmp, right, key)
+		return joinAVLNodes(left, n, l), m, r
+	default:
+		return left, n, right
+	}
+}
+
+// Split moves entries into two new trees: one having keys less than `key` and one having keys greater than or equal
+// to `key`. The tree becomes empty. This function runs in O(log n) time.
+func (t *AVLTree[K, V]) Split(key K) (left, right *AVLTree[K, V]) {
+	l, m, r := splitAVLNode(t.compare(), t.root, key)
+	if m != nil {
+		r = joinAVLNodes(nil, m, r)
+	}
+	t.root = nil
+	return t.withRoot(l), t.withRoot(r)
+}
+
+// JoinAVLTrees moves all entries of `left` and `right` into a new tree. All keys in `left` must be less than all keys
+// in `right`; otherwise, this function returns a *KeyError wrapping ErrKeyRangesOverlap. On success, `left` and `right`
+// become empty. The new tree orders keys in the same way as `left`. This function runs in O(log n) time.
+func JoinAVLTrees[K any, V any](left, right *AVLTree[K, V]) (*AVLTree[K, V], error) {
+	if left.root != nil && right.root != nil {
+		lMax := left.root.rightmost()
+		rMin := right.root.leftmost()
+		if left.compare()(lMax.split, rMin.split) >= 0 {
+			return nil, &KeyError{
+				Key: rMin.split,
+				Err: ErrKeyRangesOverlap,
+			}
+		}
+	}
+	t := left.withRoot(concatAVLNodes(left.root, right.root))
+	left.root = nil
+	right.root = nil
+	return t, nil
+}
+
+// unionAVLNodes merges two subtrees and returns a new root. When both subtrees contain the same key, `resolve` decides
+// the value. Nodes of both subtrees are reused.
+func unionAVLNodes[K any, V any](cmp func(a, b K) int, n1, n2 *avlNode[K, V], resolve func(key K, v1, v2 V) V) *avlNode[K, V] {
+	if n1 == nil {
+		return n2
+	}
+	if n2 == nil {
+		return n1
+	}
+	l1, r1 := n1.detach()
+	l2, m, r2 := splitAVLNode(cmp, n2, n1.split)
+	l := unionAVLNodes(cmp, l1, l2, resolve)
+	r := unionAVLNodes(cmp, r1, r2, resolve)
+	if m != nil {
+		n1.val = resolve(n1.split, n1.val, m.val)
+	}
+	return joinAVLNodes(l, n1, r)
+}
+
+// intersectAVLNodes returns a root of a subtree containing keys existing in both subtrees. `resolve` decides the value
+// of each key. Nodes of `n1` are reused.
+func intersectAVLNodes[K any, V any](cmp func(a, b K) int, n1, n2 *avlNode[K, V], resolve func(key K, v1, v2 V) V) *avlNode[K, V] {
+	if n1 == nil || n2 == nil {
+		return nil
+	}
+	l1, r1 := n1.detach()
+	l2, m, r2 := splitAVLNode(cmp, n2, n1.split)
+	l := intersectAVLNodes(cmp, l1, l2, resolve)
+	r := intersectAVLNodes(cmp, r1, r2, resolve)
+	if m == nil {
+		return concatAVLNodes(l, r)
+	}
+	n1.val = resolve(n1.split, n1.val, m.val)
+	return joinAVLNodes(l, n1, r)
+}
+
+// subtractAVLNodes returns a root of a subtree containing keys existing in `n1` but not in `n2`. Nodes of `n1` are
+// reused.
+func subtractAVLNodes[K any, V any](cmp func(a, b K) int, n1, n2 *avlNode[K, V]) *avlNode[K, V] {
+	if n1 == nil || n2 == nil {
+		return n1
+	}
+	l2, r2 := n2.detach()
+	l1, _, r1 := splitAVLNode(cmp, n1, n2.split)
+	l := subtractAVLNodes(cmp, l1, l2)
+	r := subtractAVLNodes(cmp, r1, r2)
+	return concatAVLNodes(l, r)
+}
+
+// keepFirst is a resolver that keeps a value of the receiver tree.
+func keepFirst[K any, V any](key K, v1, v2 V) V {
+	return v1
+}
+
+// Union moves all entries of `other` into the tree. When both trees contain the same key, `resolve` receives the key,
+// the value in the tree, and the value in `other`, and returns the value to keep. When `resolve` is nil, the value
+// in the tree is kept. `other` becomes empty. When `other` is the tree itself, the tree doesn't change and `resolve`
+// isn't called.
+// This function runs in O(m log(n/m + 1)) time, where m and n are the sizes of the smaller and the larger tree.
+func (t *AVLTree[K, V]) Union(other *AVLTree[K, V], resolve func(key K, v1, v2 V) V) {
+	if other == t {
+		return
+	}
+	if resolve == nil {
+		resolve = keepFirst[K, V]
+	}
+	t.root = unionAVLNodes(t.compare(), t.root, other.root, resolve)
+	other.root = nil
+}
+
+// Intersection keeps only entries whose keys also exist in `other`. `resolve` receives each such key, the value in
+// the tree, and the value in `other`, and returns the value to keep. When `resolve` is nil, the value in the tree is
+// kept. `other` becomes empty. When `other` is the tree itself, the tree doesn't change and `resolve` isn't called.
+// This function runs in O(m log(n/m + 1)) time, where m and n are the sizes of the smaller and the larger tree.
+func (t *AVLTree[K, V]) Intersection(other *AVLTree[K, V], resolve func(key K, v1, v2 V) V) {
+	if other == t {
+		return
+	}
+	if resolve == nil {
+		resolve = keepFirst[K, V]
+	}
+	t.root = intersectAVLNodes(t.compare(), t.root, other.root, resolve)
+	other.root = nil
+}
+
+// Difference removes entries whose keys exist in `other`. `other` becomes empty. When `other` is the tree itself, the
+// tree becomes empty.
+// This function runs in O(m log(n/m + 1)) time, where m and n are the sizes of the smaller and the larger tree.
+func (t *AVLTree[K, V]) Difference(other *AVLTree[K, V]) {
+	if other == t {
+		t.root = nil
+		return
+	}
+	t.root = subtractAVLNodes(t.compare(), t.root, other.root)
+	other.root = nil
+}
+
+// DeleteRange deletes entries whose keys are between `lo` and `hi` and returns the number of deleted entries.
+// `opts` specifies whether each end is inclusive, exclusive, or unbounded in the same way as Range; the order is
+// ignored. This function splits the tree at both ends and joins the remaining parts, so it runs in O(log n) time
+// regardless of the number of deleted entries.
+func (t *AVLTree[K, V]) DeleteRange(lo, hi K, opts RangeOptions) int {
+	var left, rest *avlNode[K, V]
+	if opts.Low == Unbounded {
+		rest = t.root
+	} else {
+		l, m, r := splitAVLNode(t.compare(), t.root, lo)
+		left, rest = l, r
+		if m != nil {
+			if opts.Low == Inclusive {
+				rest = joinAVLNodes(nil, m, rest)
+			} else {
+				left = joinAVLNodes(left, m, nil)
+			}
+		}
+	}
+
+	var deleted, right *avlNode[K, V]
+	if opts.High == Unbounded {
+		deleted = rest
+	} else {
+		l, m, r := splitAVLNode(t.compare(), rest, hi)
+		deleted, right = l, r
+		if m != nil {
+			if opts.High == Inclusive {
+				deleted = joinAVLNodes(deleted, m, nil)
+			} else {
+				right = joinAVLNodes(nil, m, right)
+			}
+		}
+	}
+
+	t.root = concatAVLNodes(left, right)
+	return deleted.subtreeSize()
+}
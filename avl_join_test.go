@@ -0,0 +1,409 @@
+package forest
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func newAVLTreeFromKeys(t *testing.T, keys []int) *AVLTree[int, int] {
+	t.Helper()
+	avl := NewAVLTree[int, int]()
+	for _, k := range keys {
+		if err := avl.Insert(k, k*10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return avl
+}
+
+func collectAVLKeys[K, V any](avl *AVLTree[K, V]) []K {
+	var keys []K
+	for k := range avl.Keys() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func intRange(lo, hi int) []int {
+	var s []int
+	for i := lo; i < hi; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func TestAVLTree_Split(t *testing.T) {
+	keys := rand.New(rand.NewSource(1)).Perm(200)
+	for _, pivot := range []int{-1, 0, 1, 50, 99, 100, 150, 199, 200, 500} {
+		avl := newAVLTreeFromKeys(t, keys)
+		left, right := avl.Split(pivot)
+		if avl.Len() != 0 {
+			t.Fatalf("the original tree must be empty. length: %v", avl.Len())
+		}
+		testAVLStructure(t, left)
+		testAVLStructure(t, right)
+
+		lo := min(max(pivot, 0), 200)
+		if k := collectAVLKeys(left); !reflect.DeepEqual(k, intRange(0, lo)) {
+			t.Fatalf("unexpected left keys on a split at %v: %v", pivot, k)
+		}
+		if k := collectAVLKeys(right); !reflect.DeepEqual(k, intRange(lo, 200)) {
+			t.Fatalf("unexpected right keys on a split at %v: %v", pivot, k)
+		}
+		if left.Len() != lo || right.Len() != 200-lo {
+			t.Fatalf("unexpected lengths on a split at %v: %v, %v", pivot, left.Len(), right.Len())
+		}
+		if v, ok := right.Search(lo); lo < 200 && (!ok || v != lo*10) {
+			t.Fatalf("unexpected value. want: %v, true, got: %v, %v", lo*10, v, ok)
+		}
+	}
+
+	t.Run("An empty tree is split into empty trees", func(t *testing.T) {
+		left, right := NewAVLTree[int, int]().Split(0)
+		if left.Len() != 0 || right.Len() != 0 {
+			t.Fatalf("unexpected lengths: %v, %v", left.Len(), right.Len())
+		}
+	})
+}
+
+func TestJoinAVLTrees(t *testing.T) {
+	tests := []struct {
+		caption string
+		left    []int
+		right   []int
+	}{
+		{
+			caption: "trees of the same size",
+			left:    intRange(0, 100),
+			right:   intRange(100, 200),
+		},
+		{
+			caption: "a taller left tree",
+			left:    intRange(0, 1000),
+			right:   intRange(1000, 1003),
+		},
+		{
+			caption: "a taller right tree",
+			left:    intRange(0, 2),
+			right:   intRange(2, 1000),
+		},
+		{
+			caption: "an empty left tree",
+			left:    nil,
+			right:   intRange(0, 10),
+		},
+		{
+			caption: "an empty right tree",
+			left:    intRange(0, 10),
+			right:   nil,
+		},
+		{
+			caption: "a single entry right tree",
+			left:    intRange(0, 100),
+			right:   []int{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			left := newAVLTreeFromKeys(t, tt.left)
+			right := newAVLTreeFromKeys(t, tt.right)
+			avl, err := JoinAVLTrees(left, right)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testAVLStructure(t, avl)
+			expected := append(append([]int{}, tt.left...), tt.right...)
+			if k := collectAVLKeys(avl); !reflect.DeepEqual(k, expected) {
+				t.Fatalf("unexpected keys: %v", k)
+			}
+			if avl.Len() != len(expected) {
+				t.Fatalf("unexpected length. want: %v, got: %v", len(expected), avl.Len())
+			}
+			if left.Len() != 0 || right.Len() != 0 {
+				t.Fatalf("joined trees must be empty. lengths: %v, %v", left.Len(), right.Len())
+			}
+		})
+	}
+
+	t.Run("Trees having overlapping key ranges cannot be joined", func(t *testing.T) {
+		left := newAVLTreeFromKeys(t, []int{1, 2, 3})
+		right := newAVLTreeFromKeys(t, []int{3, 4, 5})
+		_, err := JoinAVLTrees(left, right)
+		if !errors.Is(err, ErrKeyRangesOverlap) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyRangesOverlap, err)
+		}
+		if left.Len() != 3 || right.Len() != 3 {
+			t.Fatalf("trees must be kept on failure. lengths: %v, %v", left.Len(), right.Len())
+		}
+	})
+
+	t.Run("A split tree can be joined again", func(t *testing.T) {
+		keys := rand.New(rand.NewSource(2)).Perm(500)
+		avl := newAVLTreeFromKeys(t, keys)
+		left, right := avl.Split(123)
+		avl, err := JoinAVLTrees(left, right)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAVLStructure(t, avl)
+		if k := collectAVLKeys(avl); !reflect.DeepEqual(k, intRange(0, 500)) {
+			t.Fatalf("unexpected keys: %v", k)
+		}
+	})
+}
+
+func TestAVLTree_SetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	randomKeys := func(n, limit int) []int {
+		return r.Perm(limit)[:n]
+	}
+	sum := func(key int, v1, v2 int) int {
+		return v1 + v2
+	}
+
+	for _, sizes := range [][2]int{{0, 0}, {0, 50}, {50, 0}, {1, 300}, {300, 1}, {100, 100}, {500, 20}, {20, 500}} {
+		keys1 := randomKeys(sizes[0], 1000)
+		keys2 := randomKeys(sizes[1], 1000)
+		in1 := map[int]bool{}
+		for _, k := range keys1 {
+			in1[k] = true
+		}
+		in2 := map[int]bool{}
+		for _, k := range keys2 {
+			in2[k] = true
+		}
+
+		var union, intersection, difference []int
+		for _, k := range intRange(0, 1000) {
+			if in1[k] || in2[k] {
+				union = append(union, k)
+			}
+			if in1[k] && in2[k] {
+				intersection = append(intersection, k)
+			}
+			if in1[k] && !in2[k] {
+				difference = append(difference, k)
+			}
+		}
+		value := func(k int) int {
+			v := 0
+			if in1[k] {
+				v += k * 10
+			}
+			if in2[k] {
+				v += k * 10
+			}
+			return v
+		}
+
+		{
+			avl := newAVLTreeFromKeys(t, keys1)
+			other := newAVLTreeFromKeys(t, keys2)
+			avl.Union(other, sum)
+			testAVLStructure(t, avl)
+			if k := collectAVLKeys(avl); !reflect.DeepEqual(k, union) {
+				t.Fatalf("unexpected union of sizes %v: %v", sizes, k)
+			}
+			for k, v := range avl.All() {
+				if v != value(k) {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", k, value(k), v)
+				}
+			}
+			if other.Len() != 0 {
+				t.Fatalf("the other tree must be empty. length: %v", other.Len())
+			}
+		}
+		{
+			avl := newAVLTreeFromKeys(t, keys1)
+			other := newAVLTreeFromKeys(t, keys2)
+			avl.Intersection(other, sum)
+			testAVLStructure(t, avl)
+			if k := collectAVLKeys(avl); !reflect.DeepEqual(k, intersection) {
+				t.Fatalf("unexpected intersection of sizes %v: %v", sizes, k)
+			}
+			for k, v := range avl.All() {
+				if v != value(k) {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", k, value(k), v)
+				}
+			}
+		}
+		{
+			avl := newAVLTreeFromKeys(t, keys1)
+			other := newAVLTreeFromKeys(t, keys2)
+			avl.Difference(other)
+			testAVLStructure(t, avl)
+			if k := collectAVLKeys(avl); !reflect.DeepEqual(k, difference) {
+				t.Fatalf("unexpected difference of sizes %v: %v", sizes, k)
+			}
+		}
+	}
+
+	t.Run("An operation with the tree itself doesn't lose entries", func(t *testing.T) {
+		keys := intRange(0, 100)
+		called := false
+		resolve := func(key int, v1, v2 int) int {
+			called = true
+			return v1 + v2
+		}
+
+		avl := newAVLTreeFromKeys(t, keys)
+		avl.Union(avl, resolve)
+		testAVLStructure(t, avl)
+		if k := collectAVLKeys(avl); !reflect.DeepEqual(k, keys) {
+			t.Fatalf("unexpected union with itself: %v", k)
+		}
+
+		avl = newAVLTreeFromKeys(t, keys)
+		avl.Intersection(avl, resolve)
+		testAVLStructure(t, avl)
+		if k := collectAVLKeys(avl); !reflect.DeepEqual(k, keys) {
+			t.Fatalf("unexpected intersection with itself: %v", k)
+		}
+		if called {
+			t.Fatal("the resolver must not be called")
+		}
+		for k, v := range avl.All() {
+			if v != k*10 {
+				t.Fatalf("unexpected value of %v. want: %v, got: %v", k, k*10, v)
+			}
+		}
+
+		avl = newAVLTreeFromKeys(t, keys)
+		avl.Difference(avl)
+		if avl.Len() != 0 {
+			t.Fatalf("the difference with itself must be empty. length: %v", avl.Len())
+		}
+	})
+
+	t.Run("A nil resolver keeps values of the receiver", func(t *testing.T) {
+		avl := NewAVLTree[string, int]()
+		avl.Put("a", 1)
+		avl.Put("b", 2)
+		other := NewAVLTree[string, int]()
+		other.Put("b", 20)
+		other.Put("c", 30)
+		avl.Union(other, nil)
+		for k, expected := range map[string]int{"a": 1, "b": 2, "c": 30} {
+			if v, ok := avl.Search(k); !ok || v != expected {
+				t.Fatalf("unexpected result of %v. want: %v, true, got: %v, %v", k, expected, v, ok)
+			}
+		}
+	})
+}
+
+func TestAVLTree_DeleteRange(t *testing.T) {
+	tests := []struct {
+		caption  string
+		lo       int
+		hi       int
+		opts     RangeOptions
+		expected []int
+	}{
+		{
+			caption:  "[20, 50]",
+			lo:       20,
+			hi:       50,
+			expected: []int{10, 60, 70},
+		},
+		{
+			caption: "[20, 50)",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				High: Exclusive,
+			},
+			expected: []int{10, 50, 60, 70},
+		},
+		{
+			caption: "(20, 50]",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				Low: Exclusive,
+			},
+			expected: []int{10, 20, 60, 70},
+		},
+		{
+			caption:  "[15, 55]",
+			lo:       15,
+			hi:       55,
+			expected: []int{10, 60, 70},
+		},
+		{
+			caption: "(-inf, 30)",
+			hi:      30,
+			opts: RangeOptions{
+				Low:  Unbounded,
+				High: Exclusive,
+			},
+			expected: []int{30, 40, 50, 60, 70},
+		},
+		{
+			caption: "(50, +inf)",
+			lo:      50,
+			opts: RangeOptions{
+				Low:  Exclusive,
+				High: Unbounded,
+			},
+			expected: []int{10, 20, 30, 40, 50},
+		},
+		{
+			caption: "(-inf, +inf)",
+			opts: RangeOptions{
+				Low:  Unbounded,
+				High: Unbounded,
+			},
+			expected: nil,
+		},
+		{
+			caption:  "[31, 39]",
+			lo:       31,
+			hi:       39,
+			expected: []int{10, 20, 30, 40, 50, 60, 70},
+		},
+		{
+			caption:  "[50, 20]",
+			lo:       50,
+			hi:       20,
+			expected: []int{10, 20, 30, 40, 50, 60, 70},
+		},
+		{
+			caption:  "[30, 30]",
+			lo:       30,
+			hi:       30,
+			expected: []int{10, 20, 40, 50, 60, 70},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			avl := newAVLTreeFromKeys(t, []int{10, 20, 30, 40, 50, 60, 70})
+			n := avl.DeleteRange(tt.lo, tt.hi, tt.opts)
+			testAVLStructure(t, avl)
+			if n != 7-len(tt.expected) {
+				t.Fatalf("unexpected number of deleted entries. want: %v, got: %v", 7-len(tt.expected), n)
+			}
+			if keys := collectAVLKeys(avl); !reflect.DeepEqual(keys, tt.expected) {
+				t.Fatalf("unexpected keys. want: %v, got: %v", tt.expected, keys)
+			}
+		})
+	}
+
+	t.Run("Large ranges are deleted from a random tree", func(t *testing.T) {
+		r := rand.New(rand.NewSource(4))
+		for i := 0; i < 50; i++ {
+			avl := newAVLTreeFromKeys(t, r.Perm(1000))
+			lo, hi := r.Intn(1000), r.Intn(1000)
+			n := avl.DeleteRange(lo, hi, RangeOptions{High: Exclusive})
+			testAVLStructure(t, avl)
+			expected := max(hi-lo, 0)
+			if n != expected || avl.Len() != 1000-expected {
+				t.Fatalf("unexpected result of deleting [%v, %v): %v entries deleted, %v entries left", lo, hi, n, avl.Len())
+			}
+			if k, _, ok := avl.Ceiling(lo); lo < hi && ok && k < hi {
+				t.Fatalf("a key %v in [%v, %v) remains", k, lo, hi)
+			}
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package forest
+
+import (
+	"iter"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// AVLMultiMap is an ordered map that can associate multiple values with a key. It is an AVL tree whose entries hold
+// the values of a key in insertion order.
+type AVLMultiMap[K any, V any] struct {
+	tree *AVLTree[K, []V]
+	size int
+}
+
+// NewAVLMultiMap returns a new empty multimap that can contain entries mapping `K` to `V`.
+func NewAVLMultiMap[K constraints.Ordered, V any]() *AVLMultiMap[K, V] {
+	return &AVLMultiMap[K, V]{
+		tree: NewAVLTree[K, []V](),
+	}
+}
+
+// NewAVLMultiMapFunc returns a new empty multimap that orders keys using `cmp`.
+func NewAVLMultiMapFunc[K any, V any](cmp func(a, b K) int) *AVLMultiMap[K, V] {
+	return &AVLMultiMap[K, V]{
+		tree: NewAVLTreeFunc[K, []V](cmp),
+	}
+}
+
+// Insert appends a value to the values associated with a key.
+func (m *AVLMultiMap[K, V]) Insert(key K, value V) {
+	m.tree.Update(key, func(vs []V, _ bool) ([]V, bool) {
+		return append(vs, value), true
+	})
+	m.size++
+}
+
+// GetAll returns the values associated with a key in insertion order. The returned slice is a copy, so modifying it
+// doesn't affect the multimap. When the key doesn't exist, this function returns nil.
+func (m *AVLMultiMap[K, V]) GetAll(key K) []V {
+	vs, ok := m.tree.Search(key)
+	if !ok {
+		return nil
+	}
+	return slices.Clone(vs)
+}
+
+// Count returns the number of values associated with a key.
+func (m *AVLMultiMap[K, V]) Count(key K) int {
+	vs, _ := m.tree.Search(key)
+	return len(vs)
+}
+
+// Contains reports whether a key has at least one value.
+func (m *AVLMultiMap[K, V]) Contains(key K) bool {
+	_, ok := m.tree.Search(key)
+	return ok
+}
+
+// DeleteOne deletes the oldest value associated with a key and returns it. When the last value of the key is deleted,
+// the key is deleted too.
+func (m *AVLMultiMap[K, V]) DeleteOne(key K) (value V, found bool) {
+	m.tree.Update(key, func(vs []V, exists bool) ([]V, bool) {
+		if !exists {
+			return nil, false
+		}
+		value, found = vs[0], true
+		// Clear the deleted element so the backing array doesn't keep it reachable.
+		var zero V
+		vs[0] = zero
+		return vs[1:], len(vs) > 1
+	})
+	if found {
+		m.size--
+	}
+	return value, found
+}
+
+// DeleteAll deletes a key and all values associated with it, and returns the deleted values in insertion order.
+func (m *AVLMultiMap[K, V]) DeleteAll(key K) []V {
+	vs, ok := m.tree.Delete(key)
+	if !ok {
+		return nil
+	}
+	m.size -= len(vs)
+	return vs
+}
+
+// Len returns the number of key-value pairs. A key associated with n values is counted n times.
+func (m *AVLMultiMap[K, V]) Len() int {
+	return m.size
+}
+
+// KeyLen returns the number of distinct keys.
+func (m *AVLMultiMap[K, V]) KeyLen() int {
+	return m.tree.Len()
+}
+
+// All returns an iterator over key-value pairs in ascending order of keys. Values of the same key are visited in
+// insertion order. The multimap must not be modified during the iteration.
+func (m *AVLMultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, vs := range m.tree.All() {
+			for _, v := range vs {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over key-value pairs in descending order of keys. Values of the same key are visited
+// in reverse insertion order.
+func (m *AVLMultiMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, vs := range m.tree.Backward() {
+			for i := len(vs) - 1; i >= 0; i-- {
+				if !yield(k, vs[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over distinct keys in ascending order.
+func (m *AVLMultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.tree.Keys()
+}
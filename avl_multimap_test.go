@@ -0,0 +1,180 @@
+package forest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func collectAVLMultiMapPairs[K, V any](pairs func(yield func(K, V) bool)) []string {
+	var s []string
+	for k, v := range pairs {
+		s = append(s, fmt.Sprintf("%v:%v", k, v))
+	}
+	return s
+}
+
+func TestAVLMultiMap(t *testing.T) {
+	newMultiMap := func() *AVLMultiMap[int, string] {
+		m := NewAVLMultiMap[int, string]()
+		m.Insert(2, "b1")
+		m.Insert(1, "a1")
+		m.Insert(2, "b2")
+		m.Insert(3, "c1")
+		m.Insert(2, "b3")
+		return m
+	}
+
+	t.Run("Values of a key are kept in insertion order", func(t *testing.T) {
+		m := newMultiMap()
+		if vs := m.GetAll(2); !reflect.DeepEqual(vs, []string{"b1", "b2", "b3"}) {
+			t.Fatalf("unexpected values. want: %v, got: %v", []string{"b1", "b2", "b3"}, vs)
+		}
+		if vs := m.GetAll(4); vs != nil {
+			t.Fatalf("unexpected values. want: nil, got: %v", vs)
+		}
+		if m.Count(2) != 3 || m.Count(1) != 1 || m.Count(4) != 0 {
+			t.Fatalf("unexpected counts: %v, %v, %v", m.Count(2), m.Count(1), m.Count(4))
+		}
+		if !m.Contains(3) || m.Contains(4) {
+			t.Fatalf("unexpected result of Contains")
+		}
+		if m.Len() != 5 || m.KeyLen() != 3 {
+			t.Fatalf("unexpected lengths. want: 5, 3, got: %v, %v", m.Len(), m.KeyLen())
+		}
+	})
+
+	t.Run("GetAll returns a copy", func(t *testing.T) {
+		m := newMultiMap()
+		vs := m.GetAll(2)
+		vs[0] = "x"
+		_ = append(vs[:1], "y")
+		if vs := m.GetAll(2); !reflect.DeepEqual(vs, []string{"b1", "b2", "b3"}) {
+			t.Fatalf("the multimap was modified through a returned slice: %v", vs)
+		}
+	})
+
+	t.Run("Pairs are iterated in key order and then in insertion order", func(t *testing.T) {
+		m := newMultiMap()
+		expected := []string{"1:a1", "2:b1", "2:b2", "2:b3", "3:c1"}
+		if pairs := collectAVLMultiMapPairs(m.All()); !reflect.DeepEqual(pairs, expected) {
+			t.Fatalf("unexpected pairs. want: %v, got: %v", expected, pairs)
+		}
+		expected = []string{"3:c1", "2:b3", "2:b2", "2:b1", "1:a1"}
+		if pairs := collectAVLMultiMapPairs(m.Backward()); !reflect.DeepEqual(pairs, expected) {
+			t.Fatalf("unexpected pairs. want: %v, got: %v", expected, pairs)
+		}
+		var keys []int
+		for k := range m.Keys() {
+			keys = append(keys, k)
+		}
+		if !reflect.DeepEqual(keys, []int{1, 2, 3}) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", []int{1, 2, 3}, keys)
+		}
+	})
+
+	t.Run("An iteration stops when the loop body breaks", func(t *testing.T) {
+		m := newMultiMap()
+		var pairs []string
+		for k, v := range m.All() {
+			if k == 2 && v == "b2" {
+				break
+			}
+			pairs = append(pairs, fmt.Sprintf("%v:%v", k, v))
+		}
+		if !reflect.DeepEqual(pairs, []string{"1:a1", "2:b1"}) {
+			t.Fatalf("unexpected pairs: %v", pairs)
+		}
+	})
+
+	t.Run("DeleteOne deletes the oldest value and then the key", func(t *testing.T) {
+		m := newMultiMap()
+		for _, expected := range []string{"b1", "b2", "b3"} {
+			v, ok := m.DeleteOne(2)
+			if !ok || v != expected {
+				t.Fatalf("unexpected result. want: %v, true, got: %v, %v", expected, v, ok)
+			}
+		}
+		if _, ok := m.DeleteOne(2); ok {
+			t.Fatalf("a deleted key still has a value")
+		}
+		if m.Contains(2) || m.Len() != 2 || m.KeyLen() != 2 {
+			t.Fatalf("unexpected state: %v, %v, %v", m.Contains(2), m.Len(), m.KeyLen())
+		}
+		testAVLStructure(t, m.tree)
+	})
+
+	t.Run("DeleteAll deletes every value of a key", func(t *testing.T) {
+		m := newMultiMap()
+		if vs := m.DeleteAll(2); !reflect.DeepEqual(vs, []string{"b1", "b2", "b3"}) {
+			t.Fatalf("unexpected values. want: %v, got: %v", []string{"b1", "b2", "b3"}, vs)
+		}
+		if vs := m.DeleteAll(2); vs != nil {
+			t.Fatalf("unexpected values. want: nil, got: %v", vs)
+		}
+		if m.Len() != 2 || m.KeyLen() != 2 {
+			t.Fatalf("unexpected lengths. want: 2, 2, got: %v, %v", m.Len(), m.KeyLen())
+		}
+		testAVLStructure(t, m.tree)
+	})
+
+	t.Run("A multimap with a comparison function orders keys in the custom order", func(t *testing.T) {
+		m := NewAVLMultiMapFunc[string, int](func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+		m.Insert("b", 1)
+		m.Insert("A", 2)
+		m.Insert("B", 3)
+		expected := []string{"A:2", "b:1", "b:3"}
+		if pairs := collectAVLMultiMapPairs(m.All()); !reflect.DeepEqual(pairs, expected) {
+			t.Fatalf("unexpected pairs. want: %v, got: %v", expected, pairs)
+		}
+	})
+
+	t.Run("Random operations keep counts consistent", func(t *testing.T) {
+		r := rand.New(rand.NewSource(6))
+		m := NewAVLMultiMap[int, int]()
+		expected := map[int][]int{}
+		size := 0
+		for i := 0; i < 2000; i++ {
+			k := r.Intn(50)
+			switch r.Intn(4) {
+			case 0, 1:
+				m.Insert(k, i)
+				expected[k] = append(expected[k], i)
+				size++
+			case 2:
+				v, ok := m.DeleteOne(k)
+				if len(expected[k]) == 0 {
+					if ok {
+						t.Fatalf("DeleteOne(%v) found a value %v of a missing key", k, v)
+					}
+					break
+				}
+				if !ok || v != expected[k][0] {
+					t.Fatalf("unexpected result of DeleteOne(%v). want: %v, got: %v, %v", k, expected[k][0], v, ok)
+				}
+				expected[k] = expected[k][1:]
+				size--
+			case 3:
+				vs := m.DeleteAll(k)
+				if len(vs) != len(expected[k]) {
+					t.Fatalf("unexpected result of DeleteAll(%v). want: %v, got: %v", k, expected[k], vs)
+				}
+				size -= len(vs)
+				delete(expected, k)
+			}
+		}
+		if m.Len() != size {
+			t.Fatalf("unexpected length. want: %v, got: %v", size, m.Len())
+		}
+		for k, vs := range expected {
+			if m.Count(k) != len(vs) {
+				t.Fatalf("unexpected count of %v. want: %v, got: %v", k, len(vs), m.Count(k))
+			}
+		}
+		testAVLStructure(t, m.tree)
+	})
+}
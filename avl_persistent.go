@@ -0,0 +1,289 @@
+package forest
+
+import (
+	"fmt"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// persistentAVLNode is an immutable node of a persistent AVL tree. Unlike avlNode, it has no parent pointer, so
+// multiple versions of a tree can share it.
+type persistentAVLNode[K any, V any] struct {
+	split  K
+	left   *persistentAVLNode[K, V]
+	right  *persistentAVLNode[K, V]
+	val    V
+	height int
+	size   int
+}
+
+func newPersistentAVLNode[K any, V any](left *persistentAVLNode[K, V], split K, value V, right *persistentAVLNode[K, V]) *persistentAVLNode[K, V] {
+	return &persistentAVLNode[K, V]{
+		split:  split,
+		left:   left,
+		right:  right,
+		val:    value,
+		height: max(left.subtreeHeight(), right.subtreeHeight()) + 1,
+		size:   left.subtreeSize() + right.subtreeSize() + 1,
+	}
+}
+
+func (n *persistentAVLNode[K, V]) subtreeHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *persistentAVLNode[K, V]) subtreeSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// balancePersistentAVLNode returns a new node having `left`, `split`, `value`, and `right`. When the heights of
+// `left` and `right` differ by two, this function rotates the node by copying the nodes involved in the rotation
+// instead of modifying them.
+func balancePersistentAVLNode[K any, V any](left *persistentAVLNode[K, V], split K, value V, right *persistentAVLNode[K, V]) *persistentAVLNode[K, V] {
+	hl, hr := left.subtreeHeight(), right.subtreeHeight()
+	switch {
+	case hl > hr+1:
+		// left-heavy
+		if left.left.subtreeHeight() >= left.right.subtreeHeight() {
+			return newPersistentAVLNode(
+				left.left,
+				left.split, left.val,
+				newPersistentAVLNode(left.right, split, value, right),
+			)
+		}
+		lr := left.right
+		return newPersistentAVLNode(
+			newPersistentAVLNode(left.left, left.split, left.val, lr.left),
+			lr.split, lr.val,
+			newPersistentAVLNode(lr.right, split, value, right),
+		)
+	case hr > hl+1:
+		// right-heavy
+		if right.right.subtreeHeight() >= right.left.subtreeHeight() {
+			return newPersistentAVLNode(
+				newPersistentAVLNode(left, split, value, right.left),
+				right.split, right.val,
+				right.right,
+			)
+		}
+		rl := right.left
+		return newPersistentAVLNode(
+			newPersistentAVLNode(left, split, value, rl.left),
+			rl.split, rl.val,
+			newPersistentAVLNode(rl.right, right.split, right.val, right.right),
+		)
+	default:
+		return newPersistentAVLNode(left, split, value, right)
+	}
+}
+
+func (n *persistentAVLNode[K, V]) search(cmp func(a, b K) int, key K) (node *persistentAVLNode[K, V], found bool) {
+	for n != nil {
+		switch c := cmp(key, n.split); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// put returns a new root of a subtree in which an entry is inserted or overwritten, and reports whether the key
+// already existed. When the key exists and `overwrite` is false, this function returns the subtree itself without
+// copying any node. Only nodes on the path to the entry are copied.
+func (n *persistentAVLNode[K, V]) put(cmp func(a, b K) int, key K, value V, overwrite bool) (root *persistentAVLNode[K, V], exists bool) {
+	if n == nil {
+		return newPersistentAVLNode(nil, key, value, nil), false
+	}
+	switch c := cmp(key, n.split); {
+	case c < 0:
+		l, exists := n.left.put(cmp, key, value, overwrite)
+		if exists && !overwrite {
+			return n, true
+		}
+		return balancePersistentAVLNode(l, n.split, n.val, n.right), exists
+	case c > 0:
+		r, exists := n.right.put(cmp, key, value, overwrite)
+		if exists && !overwrite {
+			return n, true
+		}
+		return balancePersistentAVLNode(n.left, n.split, n.val, r), exists
+	default:
+		if !overwrite {
+			return n, true
+		}
+		return newPersistentAVLNode(n.left, key, value, n.right), true
+	}
+}
+
+// delete returns a new root of a subtree from which an entry is deleted. Only nodes on the path to the entry are
+// copied.
+func (n *persistentAVLNode[K, V]) delete(cmp func(a, b K) int, key K) (root *persistentAVLNode[K, V], value V, found bool) {
+	if n == nil {
+		return nil, value, false
+	}
+	switch c := cmp(key, n.split); {
+	case c < 0:
+		l, v, ok := n.left.delete(cmp, key)
+		if !ok {
+			return n, v, false
+		}
+		return balancePersistentAVLNode(l, n.split, n.val, n.right), v, true
+	case c > 0:
+		r, v, ok := n.right.delete(cmp, key)
+		if !ok {
+			return n, v, false
+		}
+		return balancePersistentAVLNode(n.left, n.split, n.val, r), v, true
+	}
+
+	switch {
+	case n.left == nil:
+		return n.right, n.val, true
+	case n.right == nil:
+		return n.left, n.val, true
+	default:
+		r, min := n.right.deleteMin()
+		return balancePersistentAVLNode(n.left, min.split, min.val, r), n.val, true
+	}
+}
+
+// deleteMin returns a new root of a subtree from which a node having the minimum key is deleted, and the deleted node.
+func (n *persistentAVLNode[K, V]) deleteMin() (root *persistentAVLNode[K, V], min *persistentAVLNode[K, V]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	l, min := n.left.deleteMin()
+	return balancePersistentAVLNode(l, n.split, n.val, n.right), min
+}
+
+// walk applies `yield` to entries of a subtree in ascending order of keys. When `yield` returns false, this function
+// stops the traversal and returns false.
+func (n *persistentAVLNode[K, V]) walk(yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.walk(yield) && yield(n.split, n.val) && n.right.walk(yield)
+}
+
+// walkBackward is like walk but traverses entries in descending order of keys.
+func (n *persistentAVLNode[K, V]) walkBackward(yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.right.walkBackward(yield) && yield(n.split, n.val) && n.left.walkBackward(yield)
+}
+
+// PersistentAVLTree is an immutable AVL tree. Insertion and deletion return a new tree that shares unchanged nodes
+// with the original one, so every tree is a snapshot that costs nothing to keep. A tree is safe for concurrent use by
+// multiple goroutines because it is never modified.
+//
+// Like AVLTree, the zero value is an empty tree ordering keys in their natural order when `K` is an ordered type. For
+// other key types, create a tree using NewPersistentAVLTreeFunc; operations on the zero value panic.
+type PersistentAVLTree[K any, V any] struct {
+	root *persistentAVLNode[K, V]
+
+	// cmp orders keys. When it is nil, keys are ordered in their natural order (see naturalOrder).
+	cmp func(a, b K) int
+}
+
+// NewPersistentAVLTree returns a new empty persistent AVL tree that can contain entries mapping `K` to `V`.
+func NewPersistentAVLTree[K constraints.Ordered, V any]() *PersistentAVLTree[K, V] {
+	return &PersistentAVLTree[K, V]{
+		cmp: compareOrdered[K],
+	}
+}
+
+// NewPersistentAVLTreeFunc returns a new empty persistent AVL tree that orders keys using `cmp`.
+func NewPersistentAVLTreeFunc[K any, V any](cmp func(a, b K) int) *PersistentAVLTree[K, V] {
+	return &PersistentAVLTree[K, V]{
+		cmp: cmp,
+	}
+}
+
+// compare returns a function ordering keys of the tree. It panics when the tree is the zero value and `K` has no
+// natural order.
+func (t *PersistentAVLTree[K, V]) compare() func(a, b K) int {
+	if t.cmp != nil {
+		return t.cmp
+	}
+	cmp, _ := naturalOrder[K, V]()
+	if cmp == nil {
+		panic(fmt.Sprintf("forest: keys of type %T have no natural order; create the PersistentAVLTree using NewPersistentAVLTreeFunc", *new(K)))
+	}
+	return cmp
+}
+
+func (t *PersistentAVLTree[K, V]) withRoot(root *persistentAVLNode[K, V]) *PersistentAVLTree[K, V] {
+	return &PersistentAVLTree[K, V]{
+		root: root,
+		cmp:  t.cmp,
+	}
+}
+
+// Insert returns a new tree containing an additional entry. When the key already exists, this function returns
+// a *KeyError wrapping ErrKeyExists.
+func (t *PersistentAVLTree[K, V]) Insert(key K, value V) (*PersistentAVLTree[K, V], error) {
+	root, exists := t.root.put(t.compare(), key, value, false)
+	if exists {
+		return nil, &KeyError{
+			Key: key,
+			Err: ErrKeyExists,
+		}
+	}
+	return t.withRoot(root), nil
+}
+
+// Put returns a new tree in which an entry is inserted or the value of an existing entry is overwritten.
+func (t *PersistentAVLTree[K, V]) Put(key K, value V) *PersistentAVLTree[K, V] {
+	root, _ := t.root.put(t.compare(), key, value, true)
+	return t.withRoot(root)
+}
+
+// Delete returns a new tree without an entry and the value of the entry. When the key doesn't exist, this function
+// returns the tree itself.
+func (t *PersistentAVLTree[K, V]) Delete(key K) (tree *PersistentAVLTree[K, V], value V, found bool) {
+	root, v, ok := t.root.delete(t.compare(), key)
+	if !ok {
+		return t, v, false
+	}
+	return t.withRoot(root), v, true
+}
+
+// Search searches for an entry having a key that exactly matches a specified key and returns its value.
+func (t *PersistentAVLTree[K, V]) Search(key K) (value V, found bool) {
+	n, ok := t.root.search(t.compare(), key)
+	if !ok {
+		return
+	}
+	return n.val, true
+}
+
+// Len returns the number of entries.
+func (t *PersistentAVLTree[K, V]) Len() int {
+	return t.root.subtreeSize()
+}
+
+// All returns an iterator over entries in ascending order of keys.
+func (t *PersistentAVLTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.root.walk(yield)
+	}
+}
+
+// Backward returns an iterator over entries in descending order of keys.
+func (t *PersistentAVLTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.root.walkBackward(yield)
+	}
+}
@@ -0,0 +1,249 @@
+package forest
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testPersistentAVLStructure[K, V any](t *testing.T, tree *PersistentAVLTree[K, V]) {
+	t.Helper()
+
+	var check func(n *persistentAVLNode[K, V]) (height int, size int)
+	check = func(n *persistentAVLNode[K, V]) (int, int) {
+		if n == nil {
+			return 0, 0
+		}
+		if n.left != nil && tree.compare()(n.left.split, n.split) >= 0 {
+			t.Fatalf("a left child %v isn't less than %v", n.left.split, n.split)
+		}
+		if n.right != nil && tree.compare()(n.right.split, n.split) <= 0 {
+			t.Fatalf("a right child %v isn't greater than %v", n.right.split, n.split)
+		}
+		lh, ls := check(n.left)
+		rh, rs := check(n.right)
+		if rh-lh < -1 || rh-lh > 1 {
+			t.Fatalf("node %v is unbalanced. left height: %v, right height: %v", n.split, lh, rh)
+		}
+		if n.height != max(lh, rh)+1 || n.size != ls+rs+1 {
+			t.Fatalf("node %v has an unexpected height or size: %v, %v", n.split, n.height, n.size)
+		}
+		return n.height, n.size
+	}
+	check(tree.root)
+}
+
+func collectPersistentAVLKeys[K, V any](tree *PersistentAVLTree[K, V]) []K {
+	var keys []K
+	for k := range tree.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestPersistentAVLTree(t *testing.T) {
+	t.Run("Old versions are unaffected by updates", func(t *testing.T) {
+		v0 := NewPersistentAVLTree[int, string]()
+		v1, err := v0.Insert(10, "10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v2, err := v1.Insert(20, "20")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v3 := v2.Put(10, "ten")
+		v4, val, ok := v3.Delete(20)
+		if !ok || val != "20" {
+			t.Fatalf("unexpected result. want: 20, true, got: %v, %v", val, ok)
+		}
+
+		if v0.Len() != 0 {
+			t.Fatalf("unexpected length. want: 0, got: %v", v0.Len())
+		}
+		if v, ok := v1.Search(10); !ok || v != "10" || v1.Len() != 1 {
+			t.Fatalf("unexpected result of v1. want: 10, true, got: %v, %v", v, ok)
+		}
+		if v, ok := v2.Search(10); !ok || v != "10" || v2.Len() != 2 {
+			t.Fatalf("unexpected result of v2. want: 10, true, got: %v, %v", v, ok)
+		}
+		if v, ok := v3.Search(10); !ok || v != "ten" || v3.Len() != 2 {
+			t.Fatalf("unexpected result of v3. want: ten, true, got: %v, %v", v, ok)
+		}
+		if _, ok := v4.Search(20); ok || v4.Len() != 1 {
+			t.Fatal("v4 must not contain 20")
+		}
+		if _, ok := v3.Search(20); !ok {
+			t.Fatal("v3 must contain 20")
+		}
+	})
+
+	t.Run("When keys are duplicated, an error occurs", func(t *testing.T) {
+		tree, err := NewPersistentAVLTree[string, int]().Insert("hello", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tree.Insert("hello", 1)
+		if !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+	})
+
+	t.Run("Insert descends the tree only once", func(t *testing.T) {
+		calls := 0
+		tree := NewPersistentAVLTreeFunc[int, int](func(a, b int) int {
+			calls++
+			return compareOrdered(a, b)
+		})
+		for i := 0; i < 1000; i++ {
+			tree = tree.Put(i*2, i)
+		}
+		height := tree.root.subtreeHeight()
+		for _, k := range []int{-1, 501, 999, 2000} {
+			calls = 0
+			if _, err := tree.Insert(k, 0); err != nil {
+				t.Fatal(err)
+			}
+			if calls > height {
+				t.Fatalf("too many comparisons to insert %v. height: %v, comparisons: %v", k, height, calls)
+			}
+		}
+		for _, k := range []int{0, 500, 1998} {
+			calls = 0
+			if _, err := tree.Insert(k, 0); !errors.Is(err, ErrKeyExists) {
+				t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+			}
+			if calls > height {
+				t.Fatalf("too many comparisons to find %v. height: %v, comparisons: %v", k, height, calls)
+			}
+		}
+	})
+
+	t.Run("The zero value orders keys in their natural order", func(t *testing.T) {
+		var zero PersistentAVLTree[time.Duration, int]
+		tree := &zero
+		for i, d := range []time.Duration{time.Second, time.Millisecond, time.Hour, time.Minute} {
+			var err error
+			tree, err = tree.Insert(d, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := tree.Insert(time.Hour, 0); !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+		tree = tree.Put(time.Hour, 10)
+		testPersistentAVLStructure(t, tree)
+		expected := []time.Duration{time.Millisecond, time.Second, time.Minute, time.Hour}
+		if keys := collectPersistentAVLKeys(tree); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", expected, keys)
+		}
+		if zero.Len() != 0 {
+			t.Fatalf("the zero value must stay empty. length: %v", zero.Len())
+		}
+	})
+
+	t.Run("Deleting a missing key returns the same tree", func(t *testing.T) {
+		tree := NewPersistentAVLTree[int, int]().Put(1, 1)
+		if deleted, _, ok := tree.Delete(2); ok || deleted != tree {
+			t.Fatalf("unexpected result: %v", ok)
+		}
+	})
+
+	t.Run("Trees stay balanced through random updates", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		tree := NewPersistentAVLTree[int, int]()
+		entries := map[int]int{}
+		var versions []*PersistentAVLTree[int, int]
+		var contents []map[int]int
+		for i := 0; i < 2000; i++ {
+			k := r.Intn(300)
+			if r.Intn(3) == 0 {
+				tree, _, _ = tree.Delete(k)
+				delete(entries, k)
+			} else {
+				tree = tree.Put(k, i)
+				entries[k] = i
+			}
+			if i%100 == 0 {
+				snapshot := map[int]int{}
+				for k, v := range entries {
+					snapshot[k] = v
+				}
+				versions = append(versions, tree)
+				contents = append(contents, snapshot)
+			}
+		}
+
+		for i, tree := range versions {
+			testPersistentAVLStructure(t, tree)
+			var keys []int
+			for k := range contents[i] {
+				keys = append(keys, k)
+			}
+			sort.Ints(keys)
+			if k := collectPersistentAVLKeys(tree); !reflect.DeepEqual(k, keys) {
+				t.Fatalf("unexpected keys of version %v: %v", i, k)
+			}
+			for k, v := range tree.All() {
+				if contents[i][k] != v {
+					t.Fatalf("unexpected value of %v in version %v. want: %v, got: %v", k, i, contents[i][k], v)
+				}
+			}
+		}
+	})
+
+	t.Run("Iterators traverse entries in order and can stop early", func(t *testing.T) {
+		tree := NewPersistentAVLTree[int, int]()
+		for _, k := range []int{5, 3, 8, 1, 4} {
+			tree = tree.Put(k, k)
+		}
+		var keys []int
+		for k := range tree.Backward() {
+			if k < 3 {
+				break
+			}
+			keys = append(keys, k)
+		}
+		if !reflect.DeepEqual(keys, []int{8, 5, 4, 3}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+	})
+
+	t.Run("Snapshots can be read while a writer creates new versions", func(t *testing.T) {
+		tree := NewPersistentAVLTree[int, int]()
+		for i := 0; i < 100; i++ {
+			tree = tree.Put(i, i)
+		}
+		snapshot := tree
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					n := 0
+					for k, v := range snapshot.All() {
+						if k != v {
+							t.Errorf("unexpected value of %v: %v", k, v)
+						}
+						n++
+					}
+					if n != 100 {
+						t.Errorf("unexpected length. want: 100, got: %v", n)
+					}
+				}
+			}()
+		}
+		for i := 0; i < 1000; i++ {
+			tree, _, _ = tree.Delete(i % 100)
+			tree = tree.Put(i%100+100, i)
+		}
+		wg.Wait()
+	})
+}
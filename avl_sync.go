@@ -0,0 +1,270 @@
+package forest
+
+import (
+	"iter"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// SyncAVLTree is an AVL tree that is safe for concurrent use by multiple goroutines. Every operation is guarded by
+// a read-write lock, and compound operations such as Update, GetOrInsert, and CompareAndSwap are atomic.
+//
+// Iterators copy the entries to visit while holding the read lock and release it before calling a loop body, so a loop
+// body sees the entries as of the start of the iteration and may call any method of the tree, including ones that
+// modify it. The copy takes O(k) time and memory for k visited entries.
+//
+// Like sync.Map, the zero value is ready to use. It orders keys in their natural order in the same way as the zero
+// value of AVLTree, so a tree whose keys aren't ordered must be created using NewSyncAVLTreeFunc. A SyncAVLTree must
+// not be copied after first use.
+type SyncAVLTree[K any, V any] struct {
+	mu   sync.RWMutex
+	tree AVLTree[K, V]
+}
+
+// NewSyncAVLTree returns a new concurrency-safe AVL tree that can contain entries mapping `K` to `V`.
+func NewSyncAVLTree[K constraints.Ordered, V any]() *SyncAVLTree[K, V] {
+	return &SyncAVLTree[K, V]{
+		tree: *NewAVLTree[K, V](),
+	}
+}
+
+// NewSyncAVLTreeFunc returns a new concurrency-safe AVL tree that orders keys using `cmp`.
+func NewSyncAVLTreeFunc[K any, V any](cmp func(a, b K) int) *SyncAVLTree[K, V] {
+	return &SyncAVLTree[K, V]{
+		tree: *NewAVLTreeFunc[K, V](cmp),
+	}
+}
+
+// Insert inserts an entry. When the key already exists, this function returns a *KeyError wrapping ErrKeyExists.
+func (t *SyncAVLTree[K, V]) Insert(key K, value V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Insert(key, value)
+}
+
+// Put inserts an entry or overwrites the value of an existing entry. When the key already exists, this function
+// returns the previous value.
+func (t *SyncAVLTree[K, V]) Put(key K, value V) (old V, replaced bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Put(key, value)
+}
+
+// Replace overwrites the value of an existing entry and returns the previous value.
+func (t *SyncAVLTree[K, V]) Replace(key K, value V) (old V, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Replace(key, value)
+}
+
+// GetOrInsert returns the value of an existing entry. When the key doesn't exist, this function atomically inserts
+// an entry with a value that `newValue` returns. `newValue` is called while the lock is held, so it must not call
+// methods of the tree.
+func (t *SyncAVLTree[K, V]) GetOrInsert(key K, newValue func() V) (value V, inserted bool) {
+	t.mu.RLock()
+	value, found := t.tree.Search(key)
+	t.mu.RUnlock()
+	if found {
+		return value, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.GetOrInsert(key, newValue)
+}
+
+// Update atomically inserts, modifies, or deletes an entry. See AVLTree.Update for the semantics of `fn`. `fn` is
+// called while the lock is held, so it must not call methods of the tree.
+func (t *SyncAVLTree[K, V]) Update(key K, fn func(old V, exists bool) (value V, keep bool)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tree.Update(key, fn)
+}
+
+// CompareAndSwap overwrites the value of an entry with `new` only when the current value equals `old`, and reports
+// whether the value was swapped. Like sync.Map, this function panics when the values aren't comparable.
+func (t *SyncAVLTree[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tree.Update(key, func(v V, exists bool) (V, bool) {
+		if !exists {
+			return v, false
+		}
+		if any(v) != any(old) {
+			return v, true
+		}
+		swapped = true
+		return new, true
+	})
+	return swapped
+}
+
+// CompareAndDelete deletes an entry only when its current value equals `old`, and reports whether the entry was
+// deleted. Like sync.Map, this function panics when the values aren't comparable.
+func (t *SyncAVLTree[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tree.Update(key, func(v V, exists bool) (V, bool) {
+		if !exists {
+			return v, false
+		}
+		if any(v) != any(old) {
+			return v, true
+		}
+		deleted = true
+		return v, false
+	})
+	return deleted
+}
+
+// Search searches for an entry having a key that exactly matches a specified key and returns its value.
+func (t *SyncAVLTree[K, V]) Search(key K) (value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Search(key)
+}
+
+// Delete deletes an entry and returns its value.
+func (t *SyncAVLTree[K, V]) Delete(key K) (value V, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.Delete(key)
+}
+
+// Len returns the number of entries.
+func (t *SyncAVLTree[K, V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Len()
+}
+
+// Min returns an entry having the minimum key.
+func (t *SyncAVLTree[K, V]) Min() (key K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Min()
+}
+
+// Max returns an entry having the maximum key.
+func (t *SyncAVLTree[K, V]) Max() (key K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Max()
+}
+
+// PopMin deletes an entry having the minimum key and returns it.
+func (t *SyncAVLTree[K, V]) PopMin() (key K, value V, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.PopMin()
+}
+
+// PopMax deletes an entry having the maximum key and returns it.
+func (t *SyncAVLTree[K, V]) PopMax() (key K, value V, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tree.PopMax()
+}
+
+// Floor returns an entry having the largest key that is less than or equal to a specified key.
+func (t *SyncAVLTree[K, V]) Floor(key K) (k K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Floor(key)
+}
+
+// Ceiling returns an entry having the smallest key that is greater than or equal to a specified key.
+func (t *SyncAVLTree[K, V]) Ceiling(key K) (k K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Ceiling(key)
+}
+
+// Lower returns an entry having the largest key that is strictly less than a specified key.
+func (t *SyncAVLTree[K, V]) Lower(key K) (k K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Lower(key)
+}
+
+// Higher returns an entry having the smallest key that is strictly greater than a specified key.
+func (t *SyncAVLTree[K, V]) Higher(key K) (k K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Higher(key)
+}
+
+// Rank returns the number of entries having keys less than a specified key and whether the key exists.
+func (t *SyncAVLTree[K, V]) Rank(key K) (rank int, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Rank(key)
+}
+
+// Select returns an entry having the i-th smallest key (0-origin).
+func (t *SyncAVLTree[K, V]) Select(i int) (key K, value V, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Select(i)
+}
+
+// Validate checks the structural invariants of the tree. See AVLTree.Validate.
+func (t *SyncAVLTree[K, V]) Validate() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree.Validate()
+}
+
+// All returns an iterator over a snapshot of entries in ascending order of keys.
+//
+// The iterator copies every entry of the tree while holding the read lock before yielding the first one, even when
+// the loop breaks early, so it takes O(n) time and memory and blocks writers meanwhile. To look up a few entries, use
+// Min, Ceiling, Higher, or Select, or use Range with bounds that cover only the entries needed.
+func (t *SyncAVLTree[K, V]) All() iter.Seq2[K, V] {
+	return t.snapshot(func(tree *AVLTree[K, V]) iter.Seq2[K, V] {
+		return tree.All()
+	})
+}
+
+// Backward returns an iterator over a snapshot of entries in descending order of keys. Like All, it copies every
+// entry while holding the read lock before yielding the first one.
+func (t *SyncAVLTree[K, V]) Backward() iter.Seq2[K, V] {
+	return t.snapshot(func(tree *AVLTree[K, V]) iter.Seq2[K, V] {
+		return tree.Backward()
+	})
+}
+
+// Range returns an iterator over a snapshot of entries whose keys are between `lo` and `hi`. See AVLTree.Range for
+// `opts`.
+//
+// The iterator copies every entry in the range while holding the read lock before yielding the first one, even when
+// the loop breaks early. The copy takes O(log n + k) time and O(k) memory for k entries in the range and blocks
+// writers meanwhile, so an unbounded range costs as much as All. To find the first entry at or above a key, use
+// Ceiling instead of breaking out of a loop over an unbounded range.
+func (t *SyncAVLTree[K, V]) Range(lo, hi K, opts RangeOptions) iter.Seq2[K, V] {
+	return t.snapshot(func(tree *AVLTree[K, V]) iter.Seq2[K, V] {
+		return tree.Range(lo, hi, opts)
+	})
+}
+
+// snapshot returns an iterator that copies entries yielded by an iterator of the underlying tree while holding the
+// read lock, and then yields the copies without the lock.
+func (t *SyncAVLTree[K, V]) snapshot(seq func(tree *AVLTree[K, V]) iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var keys []K
+		var vals []V
+		t.mu.RLock()
+		for k, v := range seq(&t.tree) {
+			keys = append(keys, k)
+			vals = append(vals, v)
+		}
+		t.mu.RUnlock()
+
+		for i, k := range keys {
+			if !yield(k, vals[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package forest
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncAVLTree(t *testing.T) {
+	t.Run("Update is atomic", func(t *testing.T) {
+		tree := NewSyncAVLTree[int, int]()
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					tree.Update(j%10, func(old int, exists bool) (int, bool) {
+						return old + 1, true
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		if tree.Len() != 10 {
+			t.Fatalf("unexpected length. want: 10, got: %v", tree.Len())
+		}
+		for k, v := range tree.All() {
+			if v != 800 {
+				t.Fatalf("unexpected value of %v. want: 800, got: %v", k, v)
+			}
+		}
+	})
+
+	t.Run("GetOrInsert constructs a value only once", func(t *testing.T) {
+		tree := NewSyncAVLTree[string, *int]()
+		var calls atomic.Int32
+		var wg sync.WaitGroup
+		values := make([]*int, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values[i], _ = tree.GetOrInsert("key", func() *int {
+					calls.Add(1)
+					return new(int)
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Fatalf("a value must be constructed once. calls: %v", calls.Load())
+		}
+		for _, v := range values {
+			if v != values[0] {
+				t.Fatal("all goroutines must get the same value")
+			}
+		}
+	})
+
+	t.Run("CompareAndSwap succeeds only once per value", func(t *testing.T) {
+		tree := NewSyncAVLTree[string, int]()
+		if err := tree.Insert("key", 0); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		var swaps atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if tree.CompareAndSwap("key", j, j+1) {
+						swaps.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if swaps.Load() != 100 {
+			t.Fatalf("unexpected number of swaps. want: 100, got: %v", swaps.Load())
+		}
+		if v, ok := tree.Search("key"); !ok || v != 100 {
+			t.Fatalf("unexpected result. want: 100, true, got: %v, %v", v, ok)
+		}
+		if tree.CompareAndSwap("missing", 0, 1) {
+			t.Fatal("a missing key must not be swapped")
+		}
+		if _, ok := tree.Search("missing"); ok {
+			t.Fatal("CompareAndSwap must not insert an entry")
+		}
+	})
+
+	t.Run("CompareAndDelete deletes only a matching entry", func(t *testing.T) {
+		tree := NewSyncAVLTree[string, int]()
+		if err := tree.Insert("key", 1); err != nil {
+			t.Fatal(err)
+		}
+		if tree.CompareAndDelete("key", 2) {
+			t.Fatal("an entry having a different value must not be deleted")
+		}
+		if !tree.CompareAndDelete("key", 1) {
+			t.Fatal("an entry having the same value must be deleted")
+		}
+		if tree.Len() != 0 {
+			t.Fatalf("unexpected length. want: 0, got: %v", tree.Len())
+		}
+	})
+
+	t.Run("Iteration sees a consistent view while writers run", func(t *testing.T) {
+		tree := NewSyncAVLTree[int, int]()
+		for i := 0; i < 100; i++ {
+			tree.Put(i, 0)
+		}
+
+		// Each writer increments all values in a single atomic batch, so every iteration must see equal values.
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				tree.mu.Lock()
+				for i := 0; i < 100; i++ {
+					tree.tree.Update(i, func(old int, exists bool) (int, bool) {
+						return old + 1, true
+					})
+				}
+				tree.mu.Unlock()
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			first := -1
+			for _, v := range tree.All() {
+				if first < 0 {
+					first = v
+				}
+				if v != first {
+					t.Errorf("inconsistent values: %v, %v", first, v)
+					break
+				}
+			}
+		}
+		close(stop)
+		wg.Wait()
+	})
+
+	t.Run("A loop body can call any method while writers run", func(t *testing.T) {
+		tree := NewSyncAVLTree[int, int]()
+		for i := 0; i < 100; i++ {
+			tree.Put(i, i)
+		}
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				tree.Put(1000+j%10, j)
+				tree.Delete(1000 + (j+5)%10)
+			}
+		}()
+
+		// Before iterators copied entries, a nested call deadlocked: a nested Delete waited for the read lock held by
+		// the iteration itself, and a nested Search waited behind the writer queued for the write lock.
+		done := make(chan struct{})
+		var forward, backward []int
+		go func() {
+			defer close(done)
+			for k := range tree.All() {
+				forward = append(forward, k)
+				tree.Search(k)
+				tree.Insert(-1-k, k)
+				for range tree.Range(k, k+10, RangeOptions{}) {
+				}
+			}
+			for k := range tree.Backward() {
+				backward = append(backward, k)
+				tree.Delete(k)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("the iteration didn't finish; a loop body calling methods of the tree must not deadlock")
+		}
+		close(stop)
+		wg.Wait()
+
+		// The first iteration must not see the negative keys that its loop body inserted.
+		var keys []int
+		for _, k := range forward {
+			if k < 0 {
+				t.Fatalf("the iteration saw a key inserted during it: %v", k)
+			}
+			if k < 1000 {
+				keys = append(keys, k)
+			}
+		}
+		if !reflect.DeepEqual(keys, intRange(0, 100)) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", intRange(0, 100), keys)
+		}
+		// The second iteration sees them, and deleting every visited key leaves only keys the writer put afterwards.
+		keys = nil
+		for _, k := range backward {
+			if k >= -100 && k < 100 {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) != 200 || keys[0] != 99 || keys[199] != -100 {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+		for k := range tree.All() {
+			if k < 1000 {
+				t.Fatalf("a key must have been deleted: %v", k)
+			}
+		}
+		testAVLStructure(t, &tree.tree)
+	})
+
+	t.Run("The zero value is ready to use", func(t *testing.T) {
+		var tree SyncAVLTree[string, int]
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					tree.Put(strconv.Itoa(i*100+j), j)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if tree.Len() != 400 {
+			t.Fatalf("unexpected length. want: 400, got: %v", tree.Len())
+		}
+		if k, _, ok := tree.Min(); !ok || k != "0" {
+			t.Fatalf("unexpected minimum. want: 0, true, got: %v, %v", k, ok)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Read operations work concurrently with writes", func(t *testing.T) {
+		tree := NewSyncAVLTree[int, int]()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					k := i*1000 + j
+					tree.Put(k, k)
+					if j%2 == 0 {
+						tree.Delete(k)
+					}
+				}
+			}(i)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					tree.Search(j)
+					tree.Floor(j)
+					tree.Rank(j)
+					tree.Min()
+					for range tree.Range(j, j+10, RangeOptions{}) {
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if tree.Len() != 1000 {
+			t.Fatalf("unexpected length. want: 1000, got: %v", tree.Len())
+		}
+		testAVLStructure(t, &tree.tree)
+	})
+}
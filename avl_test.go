@@ -1,10 +1,16 @@
 package forest
 
 import (
+	"errors"
 	"fmt"
+	"math/bits"
+	"math/rand"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAVLTree_Insert(t *testing.T) {
@@ -18,6 +24,7 @@ func TestAVLTree_Insert(t *testing.T) {
 			right.parent = n
 			n.right = right
 		}
+		n.update(nil)
 		return n
 	}
 
@@ -50,7 +57,7 @@ func TestAVLTree_Insert(t *testing.T) {
 			),
 		}
 
-		if !reflect.DeepEqual(avl, expected) {
+		if !reflect.DeepEqual(avl.root, expected.root) {
 			t.Fatal("unexpected tree")
 		}
 	})
@@ -84,7 +91,7 @@ func TestAVLTree_Insert(t *testing.T) {
 			),
 		}
 
-		if !reflect.DeepEqual(avl, expected) {
+		if !reflect.DeepEqual(avl.root, expected.root) {
 			t.Fatal("unexpected tree")
 		}
 	})
@@ -129,7 +136,7 @@ func TestAVLTree_Insert(t *testing.T) {
 			),
 		}
 
-		if !reflect.DeepEqual(avl, expected) {
+		if !reflect.DeepEqual(avl.root, expected.root) {
 			t.Fatal("unexpected tree")
 		}
 	})
@@ -174,7 +181,7 @@ func TestAVLTree_Insert(t *testing.T) {
 			),
 		}
 
-		if !reflect.DeepEqual(avl, expected) {
+		if !reflect.DeepEqual(avl.root, expected.root) {
 			t.Fatal("unexpected tree")
 		}
 	})
@@ -186,8 +193,12 @@ func TestAVLTree_Insert(t *testing.T) {
 			t.Fatal(err)
 		}
 		err = avl.Insert("hello", 0)
-		if err == nil {
-			t.Fatal("error must occur")
+		if !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != "hello" {
+			t.Fatalf("an error must report the duplicated key: %v", err)
 		}
 	})
 }
@@ -267,6 +278,7 @@ func TestAVLTree_Delete(t *testing.T) {
 			right.parent = n
 			n.right = right
 		}
+		n.update(nil)
 		return n
 	}
 
@@ -309,6 +321,32 @@ func TestAVLTree_Delete(t *testing.T) {
 				nil,
 				node(11, "11", nil, nil)),
 		},
+		//     20
+		//    /  \
+		//   10   30
+		//  /
+		// 5
+		//
+		// The predecessor 10 of the deleted root has a left child, which must be kept when 10 replaces the root.
+		{
+			entries: []int{20, 10, 30, 5},
+			delete:  20,
+			expected: node(10, "10",
+				node(5, "5", nil, nil),
+				node(30, "30", nil, nil)),
+		},
+		//     10
+		//    /  \
+		//   9    11
+		//  /
+		// 8
+		{
+			entries: []int{10, 9, 11, 8},
+			delete:  10,
+			expected: node(9, "9",
+				node(8, "8", nil, nil),
+				node(11, "11", nil, nil)),
+		},
 		//   10
 		//  /  \
 		// 8    11
@@ -717,7 +755,7 @@ func TestAVLTree_Delete(t *testing.T) {
 					node(12, "12", nil, nil))),
 		},
 
-		// rotate a left-sub tree of deleted node (rotation on `extractMax`)
+		// rotate a left-sub tree of deleted node (rotation on removing the predecessor)
 
 		// right rotation
 		//
@@ -829,12 +867,921 @@ func TestAVLTree_Delete(t *testing.T) {
 			if val, ok := avl.Delete(tt.delete); !ok || val != v {
 				t.Fatalf("unexpected result. want: %+v, true, got: %v, %v", v, val, ok)
 			}
-			e := &AVLTree[int, string]{
-				root: tt.expected,
-			}
-			if !reflect.DeepEqual(avl, e) {
+			if !reflect.DeepEqual(avl.root, tt.expected) {
 				t.Fatal("unexpected tree")
 			}
 		})
 	}
 }
+
+func TestAVLTree_All(t *testing.T) {
+	t.Run("Entries are enumerated in ascending order of keys", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range []int{10, 7, 12, 5, 8, 11, 13, 4, 6, 9, 14, 3} {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var keys []int
+		for k, v := range avl.All() {
+			if v != strconv.Itoa(k) {
+				t.Fatalf("unexpected value. want: %v, got: %v", strconv.Itoa(k), v)
+			}
+			keys = append(keys, k)
+		}
+		expected := []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+		if !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", expected, keys)
+		}
+	})
+
+	t.Run("The iteration stops when the consumer breaks", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range []int{10, 9, 13, 12, 14, 11} {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var keys []int
+		for k := range avl.All() {
+			if k > 11 {
+				break
+			}
+			keys = append(keys, k)
+		}
+		expected := []int{9, 10, 11}
+		if !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", expected, keys)
+		}
+	})
+
+	t.Run("An empty tree yields nothing", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for k, v := range avl.All() {
+			t.Fatalf("unexpected entry: %v, %v", k, v)
+		}
+		for k, v := range avl.Backward() {
+			t.Fatalf("unexpected entry: %v, %v", k, v)
+		}
+	})
+}
+
+func TestAVLTree_Backward(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	for _, e := range []int{10, 7, 12, 4, 8, 11, 13, 3, 6, 9, 14, 5} {
+		if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []int
+	for k, v := range avl.Backward() {
+		if v != strconv.Itoa(k) {
+			t.Fatalf("unexpected value. want: %v, got: %v", strconv.Itoa(k), v)
+		}
+		keys = append(keys, k)
+	}
+	expected := []int{14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("unexpected keys. want: %v, got: %v", expected, keys)
+	}
+}
+
+func TestAVLTree_KeysAndValues(t *testing.T) {
+	avl := NewAVLTree[string, int]()
+	for i, e := range []string{"cat", "ant", "dog", "bee"} {
+		if err := avl.Insert(e, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	for k := range avl.Keys() {
+		keys = append(keys, k)
+	}
+	expectedKeys := []string{"ant", "bee", "cat", "dog"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Fatalf("unexpected keys. want: %v, got: %v", expectedKeys, keys)
+	}
+
+	var values []int
+	for v := range avl.Values() {
+		values = append(values, v)
+	}
+	expectedValues := []int{1, 3, 0, 2}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Fatalf("unexpected values. want: %v, got: %v", expectedValues, values)
+	}
+}
+
+func TestAVLTree_Range(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	for _, e := range []int{10, 20, 30, 40, 50, 60, 70} {
+		if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		caption  string
+		lo       int
+		hi       int
+		opts     RangeOptions
+		expected []int
+	}{
+		{
+			caption:  "[20, 50]",
+			lo:       20,
+			hi:       50,
+			expected: []int{20, 30, 40, 50},
+		},
+		{
+			caption: "[20, 50)",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				High: Exclusive,
+			},
+			expected: []int{20, 30, 40},
+		},
+		{
+			caption: "(20, 50]",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				Low: Exclusive,
+			},
+			expected: []int{30, 40, 50},
+		},
+		{
+			caption:  "[15, 55]",
+			lo:       15,
+			hi:       55,
+			expected: []int{20, 30, 40, 50},
+		},
+		{
+			caption: "(-inf, 30]",
+			hi:      30,
+			opts: RangeOptions{
+				Low: Unbounded,
+			},
+			expected: []int{10, 20, 30},
+		},
+		{
+			caption: "(50, +inf)",
+			lo:      50,
+			opts: RangeOptions{
+				Low:  Exclusive,
+				High: Unbounded,
+			},
+			expected: []int{60, 70},
+		},
+		{
+			caption: "(-inf, +inf)",
+			opts: RangeOptions{
+				Low:  Unbounded,
+				High: Unbounded,
+			},
+			expected: []int{10, 20, 30, 40, 50, 60, 70},
+		},
+		{
+			caption: "[20, 50) in descending order",
+			lo:      20,
+			hi:      50,
+			opts: RangeOptions{
+				High:       Exclusive,
+				Descending: true,
+			},
+			expected: []int{40, 30, 20},
+		},
+		{
+			caption: "(20, +inf) in descending order",
+			lo:      20,
+			opts: RangeOptions{
+				Low:        Exclusive,
+				High:       Unbounded,
+				Descending: true,
+			},
+			expected: []int{70, 60, 50, 40, 30},
+		},
+		{
+			caption:  "[31, 39] contains nothing",
+			lo:       31,
+			hi:       39,
+			expected: nil,
+		},
+		{
+			caption:  "[50, 20] contains nothing",
+			lo:       50,
+			hi:       20,
+			expected: nil,
+		},
+		{
+			caption: "[30, 30) contains nothing",
+			lo:      30,
+			hi:      30,
+			opts: RangeOptions{
+				High: Exclusive,
+			},
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var keys []int
+			for k, v := range avl.Range(tt.lo, tt.hi, tt.opts) {
+				if v != strconv.Itoa(k) {
+					t.Fatalf("unexpected value. want: %v, got: %v", strconv.Itoa(k), v)
+				}
+				keys = append(keys, k)
+			}
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Fatalf("unexpected keys. want: %v, got: %v", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestAVLTree_Neighbors(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	for _, e := range []int{10, 20, 30, 40, 50} {
+		if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		caption  string
+		lookup   func(int) (int, string, bool)
+		key      int
+		expected int
+		found    bool
+	}{
+		{caption: "Floor of an existing key", lookup: avl.Floor, key: 30, expected: 30, found: true},
+		{caption: "Floor of a missing key", lookup: avl.Floor, key: 35, expected: 30, found: true},
+		{caption: "Floor of a too small key", lookup: avl.Floor, key: 5, found: false},
+		{caption: "Ceiling of an existing key", lookup: avl.Ceiling, key: 30, expected: 30, found: true},
+		{caption: "Ceiling of a missing key", lookup: avl.Ceiling, key: 35, expected: 40, found: true},
+		{caption: "Ceiling of a too large key", lookup: avl.Ceiling, key: 55, found: false},
+		{caption: "Lower of an existing key", lookup: avl.Lower, key: 30, expected: 20, found: true},
+		{caption: "Lower of a missing key", lookup: avl.Lower, key: 35, expected: 30, found: true},
+		{caption: "Lower of the minimum key", lookup: avl.Lower, key: 10, found: false},
+		{caption: "Higher of an existing key", lookup: avl.Higher, key: 30, expected: 40, found: true},
+		{caption: "Higher of a missing key", lookup: avl.Higher, key: 35, expected: 40, found: true},
+		{caption: "Higher of the maximum key", lookup: avl.Higher, key: 50, found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			k, v, ok := tt.lookup(tt.key)
+			if ok != tt.found {
+				t.Fatalf("unexpected result. want: %v, got: %v", tt.found, ok)
+			}
+			if !ok {
+				return
+			}
+			if k != tt.expected || v != strconv.Itoa(tt.expected) {
+				t.Fatalf("unexpected entry. want: %v, %v, got: %v, %v", tt.expected, strconv.Itoa(tt.expected), k, v)
+			}
+		})
+	}
+
+	t.Run("An empty tree has no neighbors", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		if _, _, ok := avl.Floor(0); ok {
+			t.Fatal("Floor must not find any entry")
+		}
+		if _, _, ok := avl.Ceiling(0); ok {
+			t.Fatal("Ceiling must not find any entry")
+		}
+		if _, _, ok := avl.Lower(0); ok {
+			t.Fatal("Lower must not find any entry")
+		}
+		if _, _, ok := avl.Higher(0); ok {
+			t.Fatal("Higher must not find any entry")
+		}
+	})
+}
+
+func TestAVLTree_MinMax(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	if _, _, ok := avl.Min(); ok {
+		t.Fatal("an empty tree has no minimum")
+	}
+	if _, _, ok := avl.Max(); ok {
+		t.Fatal("an empty tree has no maximum")
+	}
+
+	for _, e := range []int{10, 7, 12, 5, 8, 11, 13, 4, 6, 9, 14, 3} {
+		if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if k, v, ok := avl.Min(); !ok || k != 3 || v != "3" {
+		t.Fatalf("unexpected result. want: 3, 3, true, got: %v, %v, %v", k, v, ok)
+	}
+	if k, v, ok := avl.Max(); !ok || k != 14 || v != "14" {
+		t.Fatalf("unexpected result. want: 14, 14, true, got: %v, %v, %v", k, v, ok)
+	}
+}
+
+func TestAVLTree_PopMinAndPopMax(t *testing.T) {
+	entries := []int{10, 7, 12, 5, 8, 11, 13, 4, 6, 9, 14, 3}
+
+	t.Run("PopMin returns entries in ascending order", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range entries {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, e := range []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14} {
+			k, v, ok := avl.PopMin()
+			if !ok || k != e || v != strconv.Itoa(e) {
+				t.Fatalf("unexpected result. want: %v, %v, true, got: %v, %v, %v", e, strconv.Itoa(e), k, v, ok)
+			}
+			if _, found := avl.Search(e); found {
+				t.Fatalf("a popped key must not be found: %v", e)
+			}
+		}
+		if _, _, ok := avl.PopMin(); ok {
+			t.Fatal("an empty tree has no minimum")
+		}
+	})
+
+	t.Run("PopMax returns entries in descending order", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range entries {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, e := range []int{14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3} {
+			k, v, ok := avl.PopMax()
+			if !ok || k != e || v != strconv.Itoa(e) {
+				t.Fatalf("unexpected result. want: %v, %v, true, got: %v, %v, %v", e, strconv.Itoa(e), k, v, ok)
+			}
+			if _, found := avl.Search(e); found {
+				t.Fatalf("a popped key must not be found: %v", e)
+			}
+		}
+		if _, _, ok := avl.PopMax(); ok {
+			t.Fatal("an empty tree has no maximum")
+		}
+	})
+}
+
+func TestAVLTree_RankAndSelect(t *testing.T) {
+	t.Run("Rank and Select are inverse of each other", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range []int{10, 20, 30, 40, 50} {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if avl.Len() != 5 {
+			t.Fatalf("unexpected length. want: 5, got: %v", avl.Len())
+		}
+		for i, e := range []int{10, 20, 30, 40, 50} {
+			if r, ok := avl.Rank(e); !ok || r != i {
+				t.Fatalf("unexpected rank. want: %v, true, got: %v, %v", i, r, ok)
+			}
+			if k, v, ok := avl.Select(i); !ok || k != e || v != strconv.Itoa(e) {
+				t.Fatalf("unexpected result. want: %v, %v, true, got: %v, %v, %v", e, strconv.Itoa(e), k, v, ok)
+			}
+		}
+	})
+
+	t.Run("Rank of a missing key is the number of smaller keys", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		for _, e := range []int{10, 20, 30} {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if r, ok := avl.Rank(5); ok || r != 0 {
+			t.Fatalf("unexpected rank. want: 0, false, got: %v, %v", r, ok)
+		}
+		if r, ok := avl.Rank(25); ok || r != 2 {
+			t.Fatalf("unexpected rank. want: 2, false, got: %v, %v", r, ok)
+		}
+		if r, ok := avl.Rank(35); ok || r != 3 {
+			t.Fatalf("unexpected rank. want: 3, false, got: %v, %v", r, ok)
+		}
+	})
+
+	t.Run("Select fails when an index is out of range", func(t *testing.T) {
+		avl := NewAVLTree[int, string]()
+		if _, _, ok := avl.Select(0); ok {
+			t.Fatal("an empty tree has no entries")
+		}
+		if err := avl.Insert(10, "10"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, ok := avl.Select(-1); ok {
+			t.Fatal("a negative index must be rejected")
+		}
+		if _, _, ok := avl.Select(1); ok {
+			t.Fatal("a too large index must be rejected")
+		}
+	})
+
+	t.Run("Subtree sizes are maintained through insertions and deletions", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		avl := NewAVLTree[int, int]()
+		entries := map[int]struct{}{}
+		for i := 0; i < 2000; i++ {
+			k := r.Intn(500)
+			if r.Intn(3) == 0 {
+				_, ok := avl.Delete(k)
+				_, exist := entries[k]
+				if ok != exist {
+					t.Fatalf("unexpected result of deletion. want: %v, got: %v", exist, ok)
+				}
+				delete(entries, k)
+				continue
+			}
+			err := avl.Insert(k, k)
+			if _, exist := entries[k]; exist != (err != nil) {
+				t.Fatalf("unexpected result of insertion: %v", err)
+			}
+			entries[k] = struct{}{}
+		}
+
+		var keys []int
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Ints(keys)
+		if avl.Len() != len(keys) {
+			t.Fatalf("unexpected length. want: %v, got: %v", len(keys), avl.Len())
+		}
+		for i, e := range keys {
+			if r, ok := avl.Rank(e); !ok || r != i {
+				t.Fatalf("unexpected rank of %v. want: %v, true, got: %v, %v", e, i, r, ok)
+			}
+			if k, _, ok := avl.Select(i); !ok || k != e {
+				t.Fatalf("unexpected result of Select(%v). want: %v, true, got: %v, %v", i, e, k, ok)
+			}
+		}
+	})
+}
+
+func TestAVLTree_Height(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	avl := NewAVLTree[int, int]()
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(1000)
+		if r.Intn(3) == 0 {
+			avl.Delete(k)
+		} else {
+			_ = avl.Insert(k, k)
+		}
+		if i%100 == 0 {
+			testAVLStructure(t, avl)
+		}
+	}
+	testAVLStructure(t, avl)
+	for avl.root != nil {
+		avl.Delete(avl.root.split)
+		testAVLStructure(t, avl)
+	}
+}
+
+// testAVLStructure checks the structural invariants of a tree.
+func testAVLStructure[K, V any](t *testing.T, avl *AVLTree[K, V]) {
+	t.Helper()
+
+	if err := avl.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAVLTree_Validate(t *testing.T) {
+	newTree := func() *AVLTree[int, string] {
+		//       10
+		//      /  \
+		//     7    12
+		//    / \   /
+		//   5   8 11
+		avl := NewAVLTree[int, string]()
+		for _, e := range []int{10, 7, 12, 5, 8, 11} {
+			if err := avl.Insert(e, strconv.Itoa(e)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := avl.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		return avl
+	}
+
+	tests := []struct {
+		caption string
+		corrupt func(avl *AVLTree[int, string])
+		message string
+	}{
+		{
+			caption: "a key out of order",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.left.right.split = 13
+			},
+			message: "node 13 must be less than its ancestor 10",
+		},
+		{
+			caption: "a key equal to its ancestor",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.right.left.split = 10
+			},
+			message: "node 10 must be greater than its ancestor 10",
+		},
+		{
+			caption: "a broken parent pointer",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.left.left.parent = avl.root
+			},
+			message: "the left child 5 of node 7 doesn't point to its parent",
+		},
+		{
+			caption: "a root having a parent",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.parent = avl.root.left
+			},
+			message: "the root 10 has a parent 7",
+		},
+		{
+			caption: "an unbalanced node",
+			corrupt: func(avl *AVLTree[int, string]) {
+				n := avl.root.right
+				n.left.parent = nil
+				n.left = nil
+				n.update(nil)
+				avl.root.update(nil)
+				avl.root.left.left.left = newAVLNode(avl.root.left.left, 4, "4")
+				avl.root.left.left.update(nil)
+				avl.root.left.update(nil)
+				avl.root.update(nil)
+			},
+			message: "node 10 has a balance factor -2",
+		},
+		{
+			caption: "a wrong height",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.left.height = 5
+			},
+			message: "node 7 has a height 5, but the actual height is 2",
+		},
+		{
+			caption: "a wrong size",
+			corrupt: func(avl *AVLTree[int, string]) {
+				avl.root.size = 7
+			},
+			message: "node 10 has a size 7, but the actual size is 6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			avl := newTree()
+			tt.corrupt(avl)
+			err := avl.Validate()
+			if !errors.Is(err, ErrInvalidTree) {
+				t.Fatalf("unexpected error. want: %v, got: %v", ErrInvalidTree, err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("unexpected message. want: %v, got: %v", tt.message, err)
+			}
+		})
+	}
+
+	t.Run("An empty tree is valid", func(t *testing.T) {
+		if err := NewAVLTree[int, int]().Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func benchmarkAVLTreeSizes() []int {
+	return []int{1_000, 10_000, 100_000, 1_000_000}
+}
+
+func newBenchmarkAVLTree(b *testing.B, size int) (*AVLTree[int, int], []int) {
+	b.Helper()
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(size * 2)
+	avl := NewAVLTree[int, int]()
+	for _, k := range keys[:size] {
+		if err := avl.Insert(k, k); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return avl, keys
+}
+
+func BenchmarkAVLTree_InsertAndDelete(b *testing.B) {
+	for _, size := range benchmarkAVLTreeSizes() {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			avl, keys := newBenchmarkAVLTree(b, size)
+			absent := keys[size:]
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := absent[i%len(absent)]
+				if err := avl.Insert(k, k); err != nil {
+					b.Fatal(err)
+				}
+				avl.Delete(k)
+			}
+		})
+	}
+}
+
+func BenchmarkAVLTree_Search(b *testing.B) {
+	for _, size := range benchmarkAVLTreeSizes() {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			avl, keys := newBenchmarkAVLTree(b, size)
+			present := keys[:size]
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				avl.Search(present[i%len(present)])
+			}
+		})
+	}
+}
+
+func TestAVLTree_Put(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	for _, e := range []int{10, 20, 30} {
+		if old, replaced := avl.Put(e, strconv.Itoa(e)); replaced || old != "" {
+			t.Fatalf("unexpected result. want: \"\", false, got: %v, %v", old, replaced)
+		}
+	}
+	if old, replaced := avl.Put(20, "twenty"); !replaced || old != "20" {
+		t.Fatalf("unexpected result. want: 20, true, got: %v, %v", old, replaced)
+	}
+	if val, ok := avl.Search(20); !ok || val != "twenty" {
+		t.Fatalf("unexpected result. want: twenty, true, got: %v, %v", val, ok)
+	}
+	if avl.Len() != 3 {
+		t.Fatalf("unexpected length. want: 3, got: %v", avl.Len())
+	}
+}
+
+func TestAVLTree_Replace(t *testing.T) {
+	avl := NewAVLTree[int, string]()
+	if old, found := avl.Replace(10, "ten"); found || old != "" {
+		t.Fatalf("unexpected result. want: \"\", false, got: %v, %v", old, found)
+	}
+	if err := avl.Insert(10, "10"); err != nil {
+		t.Fatal(err)
+	}
+	if old, found := avl.Replace(10, "ten"); !found || old != "10" {
+		t.Fatalf("unexpected result. want: 10, true, got: %v, %v", old, found)
+	}
+	if old, found := avl.Replace(11, "eleven"); found || old != "" {
+		t.Fatalf("unexpected result. want: \"\", false, got: %v, %v", old, found)
+	}
+	if val, ok := avl.Search(10); !ok || val != "ten" {
+		t.Fatalf("unexpected result. want: ten, true, got: %v, %v", val, ok)
+	}
+	if _, ok := avl.Search(11); ok {
+		t.Fatal("Replace must not insert an entry")
+	}
+}
+
+func TestAVLTree_GetOrInsert(t *testing.T) {
+	avl := NewAVLTree[string, []int]()
+	calls := 0
+	newValue := func() []int {
+		calls++
+		return []int{}
+	}
+
+	v, inserted := avl.GetOrInsert("a", newValue)
+	if !inserted || v == nil {
+		t.Fatalf("unexpected result. want: [], true, got: %v, %v", v, inserted)
+	}
+	if err := avl.Insert("b", []int{2}); err != nil {
+		t.Fatal(err)
+	}
+	v, inserted = avl.GetOrInsert("b", newValue)
+	if inserted || !reflect.DeepEqual(v, []int{2}) {
+		t.Fatalf("unexpected result. want: [2], false, got: %v, %v", v, inserted)
+	}
+	if calls != 1 {
+		t.Fatalf("a value must be constructed only when a key doesn't exist. calls: %v", calls)
+	}
+}
+
+func TestAVLTree_Update(t *testing.T) {
+	increment := func(old int, exists bool) (int, bool) {
+		return old + 1, true
+	}
+	remove := func(old int, exists bool) (int, bool) {
+		return 0, false
+	}
+
+	t.Run("Update can insert, modify, and delete entries", func(t *testing.T) {
+		avl := NewAVLTree[string, int]()
+		avl.Update("a", increment)
+		avl.Update("b", increment)
+		avl.Update("a", increment)
+		if v, ok := avl.Search("a"); !ok || v != 2 {
+			t.Fatalf("unexpected result. want: 2, true, got: %v, %v", v, ok)
+		}
+		if v, ok := avl.Search("b"); !ok || v != 1 {
+			t.Fatalf("unexpected result. want: 1, true, got: %v, %v", v, ok)
+		}
+
+		avl.Update("a", remove)
+		if _, ok := avl.Search("a"); ok {
+			t.Fatal("a deleted key must not be found")
+		}
+		avl.Update("c", remove)
+		if _, ok := avl.Search("c"); ok {
+			t.Fatal("a key must not be inserted when the callback doesn't keep it")
+		}
+		if avl.Len() != 1 {
+			t.Fatalf("unexpected length. want: 1, got: %v", avl.Len())
+		}
+	})
+
+	t.Run("The tree remains balanced through updates", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		avl := NewAVLTree[int, int]()
+		entries := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(300)
+			if r.Intn(3) == 0 {
+				avl.Update(k, remove)
+				delete(entries, k)
+			} else {
+				avl.Update(k, increment)
+				entries[k]++
+			}
+		}
+
+		if avl.Len() != len(entries) {
+			t.Fatalf("unexpected length. want: %v, got: %v", len(entries), avl.Len())
+		}
+		for k, v := range avl.All() {
+			if entries[k] != v {
+				t.Fatalf("unexpected value of %v. want: %v, got: %v", k, entries[k], v)
+			}
+		}
+		if avl.root != nil && avl.root.height > 2*bits.Len(uint(avl.Len())) {
+			t.Fatalf("the tree is too tall. height: %v, length: %v", avl.root.height, avl.Len())
+		}
+	})
+}
+
+func TestNewAVLTreeFunc(t *testing.T) {
+	type tenantID struct {
+		tenant string
+		id     int
+	}
+	compareTenantID := func(a, b tenantID) int {
+		if a.tenant != b.tenant {
+			return strings.Compare(a.tenant, b.tenant)
+		}
+		return a.id - b.id
+	}
+
+	avl := NewAVLTreeFunc[tenantID, string](compareTenantID)
+	keys := []tenantID{
+		{tenant: "b", id: 2},
+		{tenant: "a", id: 10},
+		{tenant: "b", id: 1},
+		{tenant: "a", id: 3},
+		{tenant: "c", id: 0},
+	}
+	for _, k := range keys {
+		if err := avl.Insert(k, fmt.Sprintf("%v/%v", k.tenant, k.id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := avl.Insert(tenantID{tenant: "a", id: 3}, ""); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+	}
+
+	var actual []string
+	for _, v := range avl.All() {
+		actual = append(actual, v)
+	}
+	expected := []string{"a/3", "a/10", "b/1", "b/2", "c/0"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected order. want: %v, got: %v", expected, actual)
+	}
+
+	if v, ok := avl.Search(tenantID{tenant: "b", id: 1}); !ok || v != "b/1" {
+		t.Fatalf("unexpected result. want: b/1, true, got: %v, %v", v, ok)
+	}
+	if k, _, ok := avl.Ceiling(tenantID{tenant: "a", id: 11}); !ok || k != (tenantID{tenant: "b", id: 1}) {
+		t.Fatalf("unexpected result. want: {b 1}, true, got: %v, %v", k, ok)
+	}
+	if v, ok := avl.Delete(tenantID{tenant: "a", id: 10}); !ok || v != "a/10" {
+		t.Fatalf("unexpected result. want: a/10, true, got: %v, %v", v, ok)
+	}
+	if r, ok := avl.Rank(tenantID{tenant: "b", id: 2}); !ok || r != 2 {
+		t.Fatalf("unexpected rank. want: 2, true, got: %v, %v", r, ok)
+	}
+
+	var bTenant []string
+	lo := tenantID{tenant: "b"}
+	hi := tenantID{tenant: "c"}
+	for _, v := range avl.Range(lo, hi, RangeOptions{High: Exclusive}) {
+		bTenant = append(bTenant, v)
+	}
+	if !reflect.DeepEqual(bTenant, []string{"b/1", "b/2"}) {
+		t.Fatalf("unexpected range. want: [b/1 b/2], got: %v", bTenant)
+	}
+}
+
+func TestAVLTree_ZeroValue(t *testing.T) {
+	t.Run("The zero value orders keys of a predeclared type in their natural order", func(t *testing.T) {
+		var avl AVLTree[int, string]
+		for _, k := range []int{30, 10, 50, 20, 40} {
+			if err := avl.Insert(k, strconv.Itoa(k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testAVLStructure(t, &avl)
+		if err := avl.Insert(10, ""); !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+		if v, ok := avl.Search(20); !ok || v != "20" {
+			t.Fatalf("unexpected result. want: 20, true, got: %v, %v", v, ok)
+		}
+		if k, _, ok := avl.Floor(35); !ok || k != 30 {
+			t.Fatalf("unexpected result. want: 30, true, got: %v, %v", k, ok)
+		}
+		if v, ok := avl.Delete(30); !ok || v != "30" {
+			t.Fatalf("unexpected result. want: 30, true, got: %v, %v", v, ok)
+		}
+		left, right := avl.Split(40)
+		if err := left.Insert(15, "15"); err != nil {
+			t.Fatal(err)
+		}
+		testAVLStructure(t, left)
+		testAVLStructure(t, right)
+		if keys := collectAVLKeys(left); !reflect.DeepEqual(keys, []int{10, 15, 20}) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", []int{10, 15, 20}, keys)
+		}
+	})
+
+	t.Run("The zero value orders keys of a named type by the underlying type", func(t *testing.T) {
+		var durations AVLTree[time.Duration, int]
+		for i, d := range []time.Duration{time.Second, -time.Minute, time.Millisecond, time.Hour} {
+			if err := durations.Insert(d, i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testAVLStructure(t, &durations)
+		expected := []time.Duration{-time.Minute, time.Millisecond, time.Second, time.Hour}
+		if keys := collectAVLKeys(&durations); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected keys. want: %v, got: %v", expected, keys)
+		}
+		if v, ok := durations.Delete(time.Second); !ok || v != 0 {
+			t.Fatalf("unexpected result. want: 0, true, got: %v, %v", v, ok)
+		}
+
+		type name string
+		type weight float32
+		type id uint16
+		var names AVLTree[name, int]
+		var weights AVLTree[weight, int]
+		var ids AVLTree[id, int]
+		for i := 0; i < 20; i++ {
+			names.Put(name(strconv.Itoa(i)), i)
+			weights.Put(weight(10-i)/4, i)
+			ids.Put(id(i*7%20), i)
+		}
+		testAVLStructure(t, &names)
+		testAVLStructure(t, &weights)
+		testAVLStructure(t, &ids)
+		if k, _, _ := names.Min(); k != "0" {
+			t.Fatalf("unexpected minimum. want: 0, got: %v", k)
+		}
+		if k, _, _ := weights.Min(); k != -2.25 {
+			t.Fatalf("unexpected minimum. want: -2.25, got: %v", k)
+		}
+		if k, _, _ := ids.Max(); k != 19 {
+			t.Fatalf("unexpected maximum. want: 19, got: %v", k)
+		}
+	})
+
+	t.Run("The zero value panics when keys aren't ordered", func(t *testing.T) {
+		type point struct {
+			x, y int
+		}
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(fmt.Sprint(r), "no natural order") {
+				t.Fatalf("unexpected panic: %v", r)
+			}
+		}()
+		var avl AVLTree[point, string]
+		avl.Insert(point{}, "origin")
+	})
+}
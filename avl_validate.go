@@ -0,0 +1,61 @@
+package forest
+
+import "fmt"
+
+// Validate checks the structural invariants of the tree: keys are in order, every child points back to its parent,
+// cached heights and sizes are correct, and the heights of the two subtrees of every node differ by at most one.
+// When the tree violates an invariant, this function returns an error wrapping ErrInvalidTree that describes the first
+// violation found in a depth-first traversal. The order of keys and the parent pointers of a node are checked before
+// its subtrees (pre-order), and its balance, height, and size are checked after its subtrees (post-order).
+func (t *AVLTree[K, V]) Validate() error {
+	if t.root == nil {
+		return nil
+	}
+	if t.root.parent != nil {
+		return fmt.Errorf("%w: the root %v has a parent %v", ErrInvalidTree, t.root.split, t.root.parent.split)
+	}
+	_, _, err := t.root.validate(t.compare(), nil, nil)
+	return err
+}
+
+// validate checks the invariants of a subtree whose keys must be greater than `lo` and less than `hi`. A nil bound
+// means that the side is unbounded. This function returns the actual height and size of the subtree.
+func (n *avlNode[K, V]) validate(cmp func(a, b K) int, lo, hi *avlNode[K, V]) (height int, size int, err error) {
+	if n == nil {
+		return 0, 0, nil
+	}
+	if lo != nil && cmp(n.split, lo.split) <= 0 {
+		return 0, 0, fmt.Errorf("%w: node %v must be greater than its ancestor %v", ErrInvalidTree, n.split, lo.split)
+	}
+	if hi != nil && cmp(n.split, hi.split) >= 0 {
+		return 0, 0, fmt.Errorf("%w: node %v must be less than its ancestor %v", ErrInvalidTree, n.split, hi.split)
+	}
+	if n.left != nil && n.left.parent != n {
+		return 0, 0, fmt.Errorf("%w: the left child %v of node %v doesn't point to its parent", ErrInvalidTree, n.left.split, n.split)
+	}
+	if n.right != nil && n.right.parent != n {
+		return 0, 0, fmt.Errorf("%w: the right child %v of node %v doesn't point to its parent", ErrInvalidTree, n.right.split, n.split)
+	}
+
+	lh, ls, err := n.left.validate(cmp, lo, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	rh, rs, err := n.right.validate(cmp, n, hi)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if bf := rh - lh; bf < -1 || bf > 1 {
+		return 0, 0, fmt.Errorf("%w: node %v has a balance factor %v", ErrInvalidTree, n.split, bf)
+	}
+	height = max(lh, rh) + 1
+	if n.height != height {
+		return 0, 0, fmt.Errorf("%w: node %v has a height %v, but the actual height is %v", ErrInvalidTree, n.split, n.height, height)
+	}
+	size = ls + rs + 1
+	if n.size != size {
+		return 0, 0, fmt.Errorf("%w: node %v has a size %v, but the actual size is %v", ErrInvalidTree, n.split, n.size, size)
+	}
+	return height, size, nil
+}
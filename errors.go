@@ -0,0 +1,40 @@
+package forest
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrKeyExists is returned when an entry having the same key already exists.
+	ErrKeyExists = errors.New("key already exist")
+
+	// ErrEmptyKey is returned when a tree doesn't accept an empty key.
+	ErrEmptyKey = errors.New("key must not be empty")
+
+	// ErrKeyRangesOverlap is returned when trees to be joined have overlapping key ranges.
+	ErrKeyRangesOverlap = errors.New("key ranges overlap")
+
+	// ErrKeysNotSorted is returned when keys that must be in ascending order are out of order.
+	ErrKeysNotSorted = errors.New("keys are not sorted")
+
+	// ErrLengthMismatch is returned when keys and values have different lengths.
+	ErrLengthMismatch = errors.New("keys and values have different lengths")
+
+	// ErrInvalidTree is returned when a tree violates its structural invariants.
+	ErrInvalidTree = errors.New("invalid tree")
+)
+
+// KeyError records an error and a key that caused it.
+type KeyError struct {
+	Key any
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Err, e.Key)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
@@ -1,5 +1,5 @@
 module github.com/nihei9/forest-go
 
-go 1.19
+go 1.23
 
 require golang.org/x/exp v0.0.0-20230113213754-f9f960f08ad4
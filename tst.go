@@ -1,7 +1,7 @@
 package forest
 
 import (
-	"fmt"
+	"iter"
 
 	"golang.org/x/exp/constraints"
 )
@@ -15,10 +15,35 @@ type tsNode[K constraints.Ordered, V any] struct {
 	val   V
 }
 
+// unlink removes a node from the binary search tree formed by its siblings and returns a node that replaces it.
+// The eq subtree of the node is discarded, so only a node having no keys below it may be unlinked.
+func (n *tsNode[K, V]) unlink() *tsNode[K, V] {
+	switch {
+	case n.lt == nil:
+		return n.gt
+	case n.gt == nil:
+		return n.lt
+	}
+
+	// Replace the node with the minimum node among the greater siblings.
+	p := &n.gt
+	for (*p).lt != nil {
+		p = &(*p).lt
+	}
+	min := *p
+	*p = min.gt
+	min.lt, min.gt = n.lt, n.gt
+	return min
+}
+
 type TernarySearchTree[K constraints.Ordered, V any] struct {
 	root      *tsNode[K, V]
 	count     int
 	maxKeyLen int
+
+	// keyLenCounts[l] is the number of keys whose length is l. It lets maxKeyLen shrink when the longest keys are
+	// deleted.
+	keyLenCounts []int
 }
 
 // NewTernarySearchTree returns a new ternary search tree that can contain entries mapping `[]K` to `V`.
@@ -26,22 +51,38 @@ func NewTernarySearchTree[K constraints.Ordered, V any]() *TernarySearchTree[K,
 	return &TernarySearchTree[K, V]{}
 }
 
-// Insert inserts an entry. When the key already exists, this function return an error.
+// Insert inserts an entry. When the key is empty, this function returns a *KeyError wrapping ErrEmptyKey.
+// When the key already exists, this function returns a *KeyError wrapping ErrKeyExists.
 func (t *TernarySearchTree[K, V]) Insert(key []K, value V) error {
 	if len(key) == 0 {
-		return fmt.Errorf("key must not be empty")
+		return &KeyError{
+			Key: key,
+			Err: ErrEmptyKey,
+		}
 	}
 	ok := t.insertTo(&t.root, key, value)
 	if !ok {
-		return fmt.Errorf("key already exist: %v", key)
+		return &KeyError{
+			Key: key,
+			Err: ErrKeyExists,
+		}
 	}
 	t.count++
 	if len(key) > t.maxKeyLen {
 		t.maxKeyLen = len(key)
 	}
+	for len(t.keyLenCounts) <= len(key) {
+		t.keyLenCounts = append(t.keyLenCounts, 0)
+	}
+	t.keyLenCounts[len(key)]++
 	return nil
 }
 
+// Len returns the number of entries.
+func (t *TernarySearchTree[K, V]) Len() int {
+	return t.count
+}
+
 // Search earches for an entry having a key that exactly matches a specified key and returns its value.
 func (t *TernarySearchTree[K, V]) Search(key []K) (value V, found bool) {
 	if len(key) == 0 {
@@ -54,6 +95,35 @@ func (t *TernarySearchTree[K, V]) Search(key []K) (value V, found bool) {
 	return
 }
 
+// LongestPrefix searches for the longest key that is a prefix of `input` and returns the key and its value.
+func (t *TernarySearchTree[K, V]) LongestPrefix(input []K) (key []K, value V, found bool) {
+	l := 0
+	t.walkPrefixes(input, func(n int, val V) {
+		l, value, found = n, val, true
+	})
+	if !found {
+		return
+	}
+	key = make([]K, l)
+	copy(key, input)
+	return key, value, true
+}
+
+// Prefixes returns entries whose key is a prefix of `input`. The entries are sorted from the shortest key to the
+// longest one.
+func (t *TernarySearchTree[K, V]) Prefixes(input []K) []*TernarySearchTreeEntry[K, V] {
+	var entries []*TernarySearchTreeEntry[K, V]
+	t.walkPrefixes(input, func(n int, val V) {
+		key := make([]K, n)
+		copy(key, input)
+		entries = append(entries, &TernarySearchTreeEntry[K, V]{
+			Key:   key,
+			Value: val,
+		})
+	})
+	return entries
+}
+
 type TernarySearchTreeEntry[K constraints.Ordered, V any] struct {
 	Key   []K
 	Value V
@@ -83,20 +153,55 @@ func (t *TernarySearchTree[K, V]) Values(prefix []K) []V {
 	})
 }
 
-// Delete deletes an entry and returns its value.
+// All returns an iterator over entries in ascending order of keys. When a prefix isn't empty, the iterator visits
+// only entries whose key has the prefix. Entries are found lazily, so breaking out of a loop stops the traversal.
+// The tree must not be modified during the iteration.
+func (t *TernarySearchTree[K, V]) All(prefix []K) iter.Seq2[[]K, V] {
+	return t.walkPrefix(prefix, false)
+}
+
+// KeysSeq returns an iterator over keys in ascending order. When a prefix isn't empty, the iterator visits only keys
+// having the prefix.
+func (t *TernarySearchTree[K, V]) KeysSeq(prefix []K) iter.Seq[[]K] {
+	return func(yield func([]K) bool) {
+		for key := range t.walkPrefix(prefix, false) {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Scan is like All but passes the same buffer as every key to avoid allocating a slice per entry. A key is valid only
+// until the loop body for it finishes; copy it to keep it.
+func (t *TernarySearchTree[K, V]) Scan(prefix []K) iter.Seq2[[]K, V] {
+	return t.walkPrefix(prefix, true)
+}
+
+// Delete deletes an entry and returns its value. Nodes that no longer lead to any key are removed from the tree.
 func (t *TernarySearchTree[K, V]) Delete(key []K) (value V, found bool) {
 	if len(key) == 0 {
 		return
 	}
-	n := t.search(t.root, key)
-	if n == nil {
+	value, found = t.deleteFrom(&t.root, key)
+	if !found {
 		return
 	}
-	n.end = false
-	var zero V
-	val := n.val
-	n.val = zero
-	return val, true
+	t.count--
+	t.keyLenCounts[len(key)]--
+	for t.maxKeyLen > 0 && t.keyLenCounts[t.maxKeyLen] == 0 {
+		t.maxKeyLen--
+	}
+	t.keyLenCounts = t.keyLenCounts[:t.maxKeyLen+1]
+	return value, true
+}
+
+// Compact rebuilds the tree so that sibling nodes, which are linked by lt and gt, form balanced binary search trees.
+// Because the shape of a ternary search tree depends on the insertion order, inserting keys in sorted order degrades
+// every search to a linear scan of siblings; Compact restores logarithmic branching. It also removes nodes that
+// don't lead to any key.
+func (t *TernarySearchTree[K, V]) Compact() {
+	t.root = compactTSNodes(t.root)
 }
 
 func (t *TernarySearchTree[K, V]) insertTo(node **tsNode[K, V], key []K, value V) bool {
@@ -123,6 +228,114 @@ func (t *TernarySearchTree[K, V]) insertTo(node **tsNode[K, V], key []K, value V
 	}
 }
 
+// deleteFrom deletes an entry from a subtree. When a node no longer has a key or a child in the eq direction,
+// this function unlinks the node while returning from the recursion, so a dead chain is pruned up to the first node
+// shared with another key.
+func (t *TernarySearchTree[K, V]) deleteFrom(node **tsNode[K, V], key []K) (value V, found bool) {
+	n := *node
+	switch {
+	case n == nil:
+		return
+	case key[0] < n.split:
+		value, found = t.deleteFrom(&n.lt, key)
+	case key[0] > n.split:
+		value, found = t.deleteFrom(&n.gt, key)
+	case len(key) > 1:
+		value, found = t.deleteFrom(&n.eq, key[1:])
+	default:
+		if !n.end {
+			return
+		}
+		var zero V
+		value, found = n.val, true
+		n.end = false
+		n.val = zero
+	}
+	if found && !n.end && n.eq == nil {
+		*node = n.unlink()
+	}
+	return value, found
+}
+
+func (t *TernarySearchTree[K, V]) walkPrefix(prefix []K, reuseKey bool) iter.Seq2[[]K, V] {
+	return func(yield func([]K, V) bool) {
+		if len(prefix) > t.maxKeyLen {
+			return
+		}
+
+		w := &tstWalker[K, V]{
+			keyBuf:   make([]K, t.maxKeyLen),
+			reuseKey: reuseKey,
+			yield:    yield,
+		}
+
+		root := t.root
+		if len(prefix) > 0 {
+			n := t.search(t.root, prefix)
+			if n == nil {
+				return
+			}
+			copy(w.keyBuf, prefix)
+			if n.end && !w.apply(len(prefix), n.val) {
+				return
+			}
+			root = n.eq
+		}
+		w.walk(root, len(prefix))
+	}
+}
+
+// walkPrefixes follows `input` from the root and calls `fn` with the length and the value of every key that is
+// a prefix of `input`, from the shortest one to the longest one.
+func (t *TernarySearchTree[K, V]) walkPrefixes(input []K, fn func(n int, val V)) {
+	node := t.root
+	i := 0
+	for node != nil && i < len(input) {
+		switch {
+		case input[i] < node.split:
+			node = node.lt
+		case input[i] > node.split:
+			node = node.gt
+		default:
+			i++
+			if node.end {
+				fn(i, node.val)
+			}
+			node = node.eq
+		}
+	}
+}
+
+func compactTSNodes[K constraints.Ordered, V any](node *tsNode[K, V]) *tsNode[K, V] {
+	var siblings []*tsNode[K, V]
+	var collect func(n *tsNode[K, V])
+	collect = func(n *tsNode[K, V]) {
+		if n == nil {
+			return
+		}
+		collect(n.lt)
+		n.eq = compactTSNodes(n.eq)
+		if n.end || n.eq != nil {
+			siblings = append(siblings, n)
+		}
+		collect(n.gt)
+	}
+	collect(node)
+	return buildTSNodes(siblings)
+}
+
+// buildTSNodes links sorted sibling nodes into a balanced binary search tree and returns its root.
+func buildTSNodes[K constraints.Ordered, V any](siblings []*tsNode[K, V]) *tsNode[K, V] {
+	if len(siblings) == 0 {
+		return nil
+	}
+	mid := len(siblings) / 2
+	n := siblings[mid]
+	n.lt = buildTSNodes(siblings[:mid])
+	n.gt = buildTSNodes(siblings[mid+1:])
+	return n
+}
+
 func (t *TernarySearchTree[K, V]) search(node *tsNode[K, V], prefix []K) *tsNode[K, V] {
 	switch {
 	case node == nil:
@@ -141,61 +354,45 @@ func (t *TernarySearchTree[K, V]) search(node *tsNode[K, V], prefix []K) *tsNode
 
 // ApplyToTernarySearchTree applies a user-defined function to each entry whose key has a specified prefix.
 func ApplyToTernarySearchTree[K constraints.Ordered, V any, R any](t *TernarySearchTree[K, V], prefix []K, callback func([]K, V) R) []R {
-	if len(prefix) > t.maxKeyLen {
-		return nil
-	}
-
-	w := &tstWalker[K, V, R]{
-		keyBuf:   make([]K, t.maxKeyLen),
-		results:  make([]R, t.count),
-		callback: callback,
+	var results []R
+	for key, val := range t.All(prefix) {
+		results = append(results, callback(key, val))
 	}
-
-	root := t.root
-	if len(prefix) > 0 {
-		n := t.search(t.root, prefix)
-		if n == nil {
-			return nil
-		}
-		copy(w.keyBuf, prefix)
-		if n.end {
-			w.apply(len(prefix), n.val)
-		}
-		root = n.eq
-	}
-	w.walk(root, len(prefix))
-	return w.results[:w.resultPtr]
+	return results
 }
 
-type tstWalker[K constraints.Ordered, V any, R any] struct {
-	keyBuf    []K
-	results   []R
-	resultPtr int
-	callback  func([]K, V) R
+// tstWalker traverses a subtree in ascending order of keys and passes each entry to `yield` until it returns false.
+type tstWalker[K constraints.Ordered, V any] struct {
+	keyBuf   []K
+	reuseKey bool
+	yield    func([]K, V) bool
 }
 
-func (w *tstWalker[K, V, R]) walk(node *tsNode[K, V], bufPtr int) {
+func (w *tstWalker[K, V]) walk(node *tsNode[K, V], bufPtr int) bool {
 	if node == nil {
-		return
+		return true
 	}
 
-	w.walk(node.lt, bufPtr)
+	if !w.walk(node.lt, bufPtr) {
+		return false
+	}
 
-	if node.end {
-		w.keyBuf[bufPtr] = node.split
-		w.apply(bufPtr+1, node.val)
+	w.keyBuf[bufPtr] = node.split
+	if node.end && !w.apply(bufPtr+1, node.val) {
+		return false
 	}
-	if node.eq != nil {
-		w.keyBuf[bufPtr] = node.split
-		w.walk(node.eq, bufPtr+1)
+	if node.eq != nil && !w.walk(node.eq, bufPtr+1) {
+		return false
 	}
 
-	w.walk(node.gt, bufPtr)
+	return w.walk(node.gt, bufPtr)
 }
 
-func (w *tstWalker[K, V, R]) apply(end int, val V) {
+func (w *tstWalker[K, V]) apply(end int, val V) bool {
+	if w.reuseKey {
+		return w.yield(w.keyBuf[:end], val)
+	}
 	key := make([]K, end)
 	copy(key, w.keyBuf[:end])
-	w.results[w.resultPtr] = w.callback(key, val)
-	w.resultPtr++
+	return w.yield(key, val)
 }
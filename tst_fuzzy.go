@@ -0,0 +1,103 @@
+package forest
+
+import (
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// FuzzySearchOptions specifies how FuzzySearch measures the distance between keys. The zero value uses
+// the Levenshtein distance.
+type FuzzySearchOptions struct {
+	// Transpositions makes swapping two adjacent elements cost one edit instead of two, that is, FuzzySearch uses
+	// the optimal string alignment variant of the Damerau-Levenshtein distance.
+	Transpositions bool
+}
+
+// TernarySearchTreeFuzzyMatch is an entry found by FuzzySearch.
+type TernarySearchTreeFuzzyMatch[K constraints.Ordered, V any] struct {
+	Key      []K
+	Value    V
+	Distance int
+}
+
+// FuzzySearch returns entries whose key is within `maxDistance` edits of `key`. The entries are sorted by distance
+// and then by key. Branches whose every key is farther than `maxDistance` are skipped, so this function doesn't
+// enumerate the whole tree.
+func (t *TernarySearchTree[K, V]) FuzzySearch(key []K, maxDistance int, opts FuzzySearchOptions) []*TernarySearchTreeFuzzyMatch[K, V] {
+	if maxDistance < 0 || t.root == nil {
+		return nil
+	}
+
+	// rows[d][j] is the distance between the first d elements of the current path and the first j elements of `key`.
+	rows := make([][]int, t.maxKeyLen+1)
+	for d := range rows {
+		rows[d] = make([]int, len(key)+1)
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	s := &tstFuzzySearcher[K, V]{
+		key:            key,
+		maxDistance:    maxDistance,
+		transpositions: opts.Transpositions,
+		keyBuf:         make([]K, t.maxKeyLen),
+		rows:           rows,
+	}
+	s.walk(t.root, 0)
+
+	// The walk finds entries in ascending order of keys, so a stable sort keeps that order among equal distances.
+	slices.SortStableFunc(s.results, func(a, b *TernarySearchTreeFuzzyMatch[K, V]) int {
+		return a.Distance - b.Distance
+	})
+	return s.results
+}
+
+type tstFuzzySearcher[K constraints.Ordered, V any] struct {
+	key            []K
+	maxDistance    int
+	transpositions bool
+	keyBuf         []K
+	rows           [][]int
+	results        []*TernarySearchTreeFuzzyMatch[K, V]
+}
+
+func (s *tstFuzzySearcher[K, V]) walk(node *tsNode[K, V], depth int) {
+	if node == nil {
+		return
+	}
+
+	s.walk(node.lt, depth)
+
+	s.keyBuf[depth] = node.split
+	prev, row := s.rows[depth], s.rows[depth+1]
+	row[0] = depth + 1
+	minDist := row[0]
+	for j := 1; j <= len(s.key); j++ {
+		cost := 1
+		if s.key[j-1] == node.split {
+			cost = 0
+		}
+		row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+		if s.transpositions && depth > 0 && j > 1 && s.key[j-1] == s.keyBuf[depth-1] && s.key[j-2] == node.split {
+			row[j] = min(row[j], s.rows[depth-1][j-2]+1)
+		}
+		minDist = min(minDist, row[j])
+	}
+	if node.end && row[len(s.key)] <= s.maxDistance {
+		key := make([]K, depth+1)
+		copy(key, s.keyBuf)
+		s.results = append(s.results, &TernarySearchTreeFuzzyMatch[K, V]{
+			Key:      key,
+			Value:    node.val,
+			Distance: row[len(s.key)],
+		})
+	}
+	// Every distance in the next rows is at least the minimum of this row, so the eq branch can't contain a match
+	// when the minimum exceeds the limit.
+	if minDist <= s.maxDistance {
+		s.walk(node.eq, depth+1)
+	}
+
+	s.walk(node.gt, depth)
+}
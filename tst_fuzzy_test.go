@@ -0,0 +1,144 @@
+package forest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTernarySearchTree_FuzzySearch(t *testing.T) {
+	tst := NewTernarySearchTree[rune, int]()
+	for i, key := range []string{"hello", "help", "hell", "shell", "yellow", "world", "ehllo", "h"} {
+		if err := tst.Insert([]rune(key), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		caption     string
+		key         string
+		maxDistance int
+		opts        FuzzySearchOptions
+		expected    []string
+	}{
+		{
+			caption:     "An exact match has distance 0",
+			key:         "hello",
+			maxDistance: 0,
+			expected:    []string{"hello:0"},
+		},
+		{
+			caption:     "Matches are sorted by distance and then by key",
+			key:         "hello",
+			maxDistance: 1,
+			expected:    []string{"hello:0", "hell:1"},
+		},
+		{
+			caption:     "A swap costs two edits without transpositions",
+			key:         "hello",
+			maxDistance: 2,
+			expected:    []string{"hello:0", "hell:1", "ehllo:2", "help:2", "shell:2", "yellow:2"},
+		},
+		{
+			caption:     "A swap costs one edit with transpositions",
+			key:         "hello",
+			maxDistance: 1,
+			opts: FuzzySearchOptions{
+				Transpositions: true,
+			},
+			expected: []string{"hello:0", "ehllo:1", "hell:1"},
+		},
+		{
+			caption:     "An empty key matches short keys",
+			key:         "",
+			maxDistance: 1,
+			expected:    []string{"h:1"},
+		},
+		{
+			caption:     "A negative distance matches nothing",
+			key:         "hello",
+			maxDistance: -1,
+			expected:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caption, func(t *testing.T) {
+			var matches []string
+			for _, m := range tst.FuzzySearch([]rune(tt.key), tt.maxDistance, tt.opts) {
+				if v, _ := tst.Search(m.Key); v != m.Value {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", string(m.Key), v, m.Value)
+				}
+				matches = append(matches, fmt.Sprintf("%v:%v", string(m.Key), m.Distance))
+			}
+			if !reflect.DeepEqual(matches, tt.expected) {
+				t.Fatalf("unexpected matches. want: %v, got: %v", tt.expected, matches)
+			}
+		})
+	}
+
+	t.Run("Results equal those of a brute-force search", func(t *testing.T) {
+		r := rand.New(rand.NewSource(8))
+		randomKey := func() []byte {
+			key := make([]byte, r.Intn(7)+1)
+			for i := range key {
+				key[i] = "abc"[r.Intn(3)]
+			}
+			return key
+		}
+		tst := NewTernarySearchTree[byte, int]()
+		var keys [][]byte
+		for i := 0; i < 300; i++ {
+			key := randomKey()
+			if tst.Insert(key, i) == nil {
+				keys = append(keys, key)
+			}
+		}
+		for i := 0; i < 100; i++ {
+			key := randomKey()
+			maxDistance := r.Intn(3)
+			for _, transpositions := range []bool{false, true} {
+				expected := map[string]int{}
+				for _, k := range keys {
+					if d := editDistance(k, key, transpositions); d <= maxDistance {
+						expected[string(k)] = d
+					}
+				}
+				matches := tst.FuzzySearch(key, maxDistance, FuzzySearchOptions{Transpositions: transpositions})
+				if len(matches) != len(expected) {
+					t.Fatalf("unexpected number of matches of %s. want: %v, got: %v", key, len(expected), len(matches))
+				}
+				for _, m := range matches {
+					if d, ok := expected[string(m.Key)]; !ok || d != m.Distance {
+						t.Fatalf("unexpected match of %s: %s, %v", key, m.Key, m.Distance)
+					}
+				}
+			}
+		}
+	})
+}
+
+// editDistance computes the Levenshtein distance or the optimal string alignment distance with the full matrix.
+func editDistance(a, b []byte, transpositions bool) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if transpositions && i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
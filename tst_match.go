@@ -0,0 +1,119 @@
+package forest
+
+import "golang.org/x/exp/constraints"
+
+// Wildcards specifies the elements that Match treats as wildcards. `One` matches exactly one element, and `Many`
+// matches zero or more elements. The two tokens must differ, and a pattern can't match them literally.
+type Wildcards[K constraints.Ordered] struct {
+	One  K
+	Many K
+}
+
+// Match returns entries whose key matches `pattern` in the same order as Entries. Siblings of a node are visited only
+// when a wildcard or an element of the pattern may match them, so a pattern starting with literal elements narrows
+// the search like a prefix does.
+func (t *TernarySearchTree[K, V]) Match(pattern []K, wildcards Wildcards[K]) []*TernarySearchTreeEntry[K, V] {
+	if len(pattern) == 0 || t.root == nil {
+		return nil
+	}
+
+	// states[d][i] reports whether the first d elements of the current path can match the first i elements of
+	// `pattern`; states[d][len(pattern)] means the path matches the whole pattern.
+	states := make([][]bool, t.maxKeyLen+1)
+	for d := range states {
+		states[d] = make([]bool, len(pattern)+1)
+	}
+	m := &tstMatcher[K, V]{
+		pattern:   pattern,
+		wildcards: wildcards,
+		keyBuf:    make([]K, t.maxKeyLen),
+		states:    states,
+	}
+	states[0][0] = true
+	m.closure(states[0])
+	m.walk(t.root, 0)
+	return m.results
+}
+
+type tstMatcher[K constraints.Ordered, V any] struct {
+	pattern   []K
+	wildcards Wildcards[K]
+	keyBuf    []K
+	states    [][]bool
+	results   []*TernarySearchTreeEntry[K, V]
+}
+
+func (m *tstMatcher[K, V]) walk(node *tsNode[K, V], depth int) {
+	if node == nil {
+		return
+	}
+
+	lt, eq, gt := m.directions(m.states[depth], node.split)
+	if lt {
+		m.walk(node.lt, depth)
+	}
+	if eq && m.step(m.states[depth], m.states[depth+1], node.split) {
+		m.keyBuf[depth] = node.split
+		if node.end && m.states[depth+1][len(m.pattern)] {
+			key := make([]K, depth+1)
+			copy(key, m.keyBuf)
+			m.results = append(m.results, &TernarySearchTreeEntry[K, V]{
+				Key:   key,
+				Value: node.val,
+			})
+		}
+		m.walk(node.eq, depth+1)
+	}
+	if gt {
+		m.walk(node.gt, depth)
+	}
+}
+
+// directions reports which of the lesser siblings, the node itself, and the greater siblings may match the next
+// element of a path.
+func (m *tstMatcher[K, V]) directions(cur []bool, split K) (lt, eq, gt bool) {
+	for i, p := range m.pattern {
+		if !cur[i] {
+			continue
+		}
+		if p == m.wildcards.One || p == m.wildcards.Many {
+			return true, true, true
+		}
+		lt = lt || p < split
+		eq = eq || p == split
+		gt = gt || p > split
+	}
+	return lt, eq, gt
+}
+
+// step computes states after consuming `elem` from `cur` into `next` and reports whether any state remains.
+func (m *tstMatcher[K, V]) step(cur, next []bool, elem K) bool {
+	clear(next)
+	for i, p := range m.pattern {
+		if !cur[i] {
+			continue
+		}
+		switch p {
+		case m.wildcards.Many:
+			next[i] = true
+		case m.wildcards.One, elem:
+			next[i+1] = true
+		}
+	}
+	m.closure(next)
+	for _, s := range next {
+		if s {
+			return true
+		}
+	}
+	return false
+}
+
+// closure adds states reachable by matching a multi-element wildcard with no elements.
+func (m *tstMatcher[K, V]) closure(states []bool) {
+	for i, p := range m.pattern {
+		if states[i] && p == m.wildcards.Many {
+			states[i+1] = true
+		}
+	}
+}
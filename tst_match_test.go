@@ -0,0 +1,135 @@
+package forest
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTernarySearchTree_Match(t *testing.T) {
+	tst := NewTernarySearchTree[rune, int]()
+	for i, key := range []string{"hello", "hallo", "hull", "hell", "help", "shell", "hollow", "hallway", "h", "ll"} {
+		if err := tst.Insert([]rune(key), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wildcards := Wildcards[rune]{
+		One:  '?',
+		Many: '*',
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{
+			pattern:  "h?ll*",
+			expected: []string{"hallo", "hallway", "hell", "hello", "hollow", "hull"},
+		},
+		{
+			pattern:  "h?ll",
+			expected: []string{"hell", "hull"},
+		},
+		{
+			pattern:  "*ll",
+			expected: []string{"hell", "hull", "ll", "shell"},
+		},
+		{
+			pattern:  "*l*l*",
+			expected: []string{"hallo", "hallway", "hell", "hello", "hollow", "hull", "ll", "shell"},
+		},
+		{
+			pattern:  "hel?",
+			expected: []string{"hell", "help"},
+		},
+		{
+			pattern:  "help",
+			expected: []string{"help"},
+		},
+		{
+			pattern:  "h*",
+			expected: []string{"h", "hallo", "hallway", "hell", "hello", "help", "hollow", "hull"},
+		},
+		{
+			pattern:  "?",
+			expected: []string{"h"},
+		},
+		{
+			pattern:  "**o",
+			expected: []string{"hallo", "hello"},
+		},
+		{
+			pattern:  "x*",
+			expected: nil,
+		},
+		{
+			pattern:  "",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			var keys []string
+			for _, e := range tst.Match([]rune(tt.pattern), wildcards) {
+				if v, _ := tst.Search(e.Key); v != e.Value {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", string(e.Key), v, e.Value)
+				}
+				keys = append(keys, string(e.Key))
+			}
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Fatalf("unexpected keys. want: %v, got: %v", tt.expected, keys)
+			}
+		})
+	}
+
+	t.Run("Results equal those of a brute-force search in the order of Entries", func(t *testing.T) {
+		r := rand.New(rand.NewSource(9))
+		randomKey := func(alphabet string) []byte {
+			key := make([]byte, r.Intn(6)+1)
+			for i := range key {
+				key[i] = alphabet[r.Intn(len(alphabet))]
+			}
+			return key
+		}
+		tst := NewTernarySearchTree[byte, int]()
+		for i := 0; i < 300; i++ {
+			tst.Insert(randomKey("abc"), i)
+		}
+		wildcards := Wildcards[byte]{
+			One:  '?',
+			Many: '*',
+		}
+		for i := 0; i < 200; i++ {
+			pattern := randomKey("abc?*")
+			var expected []*TernarySearchTreeEntry[byte, int]
+			for _, e := range tst.Entries(nil) {
+				if matchPattern(pattern, e.Key, wildcards) {
+					expected = append(expected, e)
+				}
+			}
+			if matches := tst.Match(pattern, wildcards); !reflect.DeepEqual(matches, expected) {
+				t.Fatalf("unexpected matches of %s. want: %v entries, got: %v entries", pattern, len(expected), len(matches))
+			}
+		}
+	})
+}
+
+// matchPattern matches a pattern against a key with backtracking.
+func matchPattern(pattern, key []byte, wildcards Wildcards[byte]) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case wildcards.Many:
+		for i := 0; i <= len(key); i++ {
+			if matchPattern(pattern[1:], key[i:], wildcards) {
+				return true
+			}
+		}
+		return false
+	case wildcards.One:
+		return len(key) > 0 && matchPattern(pattern[1:], key[1:], wildcards)
+	default:
+		return len(key) > 0 && key[0] == pattern[0] && matchPattern(pattern[1:], key[1:], wildcards)
+	}
+}
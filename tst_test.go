@@ -1,7 +1,10 @@
 package forest
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"sort"
 	"testing"
@@ -49,24 +52,28 @@ func TestTernarySearchTree_Insert(t *testing.T) {
 			t.Fatal(err)
 		}
 		err = tst.Insert([]rune("hello"), 0)
-		if err == nil {
-			t.Fatal("error must occur")
+		if !errors.Is(err, ErrKeyExists) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrKeyExists, err)
+		}
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || !reflect.DeepEqual(keyErr.Key, []rune("hello")) {
+			t.Fatalf("an error must report the duplicated key: %v", err)
 		}
 	})
 
 	t.Run("A key must not be empty", func(t *testing.T) {
 		tst := NewTernarySearchTree[rune, int]()
 		err := tst.Insert([]rune(""), 0)
-		if err == nil {
-			t.Fatal("error must occur")
+		if !errors.Is(err, ErrEmptyKey) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrEmptyKey, err)
 		}
 		err = tst.Insert([]rune{}, 0)
-		if err == nil {
-			t.Fatal("error must occur")
+		if !errors.Is(err, ErrEmptyKey) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrEmptyKey, err)
 		}
 		err = tst.Insert(nil, 0)
-		if err == nil {
-			t.Fatal("error must occur")
+		if !errors.Is(err, ErrEmptyKey) {
+			t.Fatalf("unexpected error. want: %v, got: %v", ErrEmptyKey, err)
 		}
 	})
 }
@@ -152,6 +159,89 @@ func TestTernarySearchTree_Search(t *testing.T) {
 	})
 }
 
+func TestTernarySearchTree_LongestPrefix(t *testing.T) {
+	tst := NewTernarySearchTree[rune, int]()
+	for i, key := range []string{"he", "hell", "hello", "help", "world"} {
+		if err := tst.Insert([]rune(key), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		key      string
+		value    int
+		found    bool
+		prefixes []string
+	}{
+		{
+			input:    "hello, world",
+			key:      "hello",
+			value:    2,
+			found:    true,
+			prefixes: []string{"he", "hell", "hello"},
+		},
+		{
+			input:    "hello",
+			key:      "hello",
+			value:    2,
+			found:    true,
+			prefixes: []string{"he", "hell", "hello"},
+		},
+		{
+			input:    "help!",
+			key:      "help",
+			value:    3,
+			found:    true,
+			prefixes: []string{"he", "help"},
+		},
+		{
+			input:    "hel",
+			key:      "he",
+			value:    0,
+			found:    true,
+			prefixes: []string{"he"},
+		},
+		{
+			input: "h",
+		},
+		{
+			input: "ahello",
+		},
+		{
+			input: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			key, val, ok := tst.LongestPrefix([]rune(tt.input))
+			if ok != tt.found || string(key) != tt.key || val != tt.value {
+				t.Fatalf("unexpected result. want: %v, %v, %v, got: %v, %v, %v", tt.key, tt.value, tt.found, string(key), val, ok)
+			}
+
+			var prefixes []string
+			for _, e := range tst.Prefixes([]rune(tt.input)) {
+				if v, _ := tst.Search(e.Key); v != e.Value {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", string(e.Key), v, e.Value)
+				}
+				prefixes = append(prefixes, string(e.Key))
+			}
+			if !reflect.DeepEqual(prefixes, tt.prefixes) {
+				t.Fatalf("unexpected prefixes. want: %v, got: %v", tt.prefixes, prefixes)
+			}
+		})
+	}
+
+	t.Run("A returned key doesn't share memory with the input", func(t *testing.T) {
+		input := []rune("hello")
+		key, _, _ := tst.LongestPrefix(input)
+		key[0] = 'j'
+		if string(input) != "hello" {
+			t.Fatalf("the input was modified: %v", string(input))
+		}
+	})
+}
+
 func TestTernarySearchTree_Keys(t *testing.T) {
 	prettier := func(runeSeq []rune) string {
 		return string(runeSeq)
@@ -234,6 +324,100 @@ func TestTernarySearchTree_Keys(t *testing.T) {
 	})
 }
 
+func TestTernarySearchTree_All(t *testing.T) {
+	tst := NewTernarySearchTree[rune, int]()
+	for i, key := range []string{"healthy", "hello", "world", "hell", "hello😺", "heaven"} {
+		if err := tst.Insert([]rune(key), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{
+			prefix:   "",
+			expected: []string{"healthy", "heaven", "hell", "hello", "hello😺", "world"},
+		},
+		{
+			prefix:   "hell",
+			expected: []string{"hell", "hello", "hello😺"},
+		},
+		{
+			prefix:   "hea",
+			expected: []string{"healthy", "heaven"},
+		},
+		{
+			prefix:   "x",
+			expected: nil,
+		},
+		{
+			prefix:   "hello😺😺",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			var keys, scanned, seq []string
+			for key, val := range tst.All([]rune(tt.prefix)) {
+				if v, _ := tst.Search(key); v != val {
+					t.Fatalf("unexpected value of %v. want: %v, got: %v", string(key), v, val)
+				}
+				keys = append(keys, string(key))
+			}
+			for key := range tst.Scan([]rune(tt.prefix)) {
+				scanned = append(scanned, string(key))
+			}
+			for key := range tst.KeysSeq([]rune(tt.prefix)) {
+				seq = append(seq, string(key))
+			}
+			if !reflect.DeepEqual(keys, tt.expected) || !reflect.DeepEqual(scanned, tt.expected) || !reflect.DeepEqual(seq, tt.expected) {
+				t.Fatalf("unexpected keys. want: %v, got: %v, %v, %v", tt.expected, keys, scanned, seq)
+			}
+		})
+	}
+
+	t.Run("An iteration stops when the loop body breaks", func(t *testing.T) {
+		var keys []string
+		for key := range tst.All(nil) {
+			if string(key) == "hello" {
+				break
+			}
+			keys = append(keys, string(key))
+		}
+		if !reflect.DeepEqual(keys, []string{"healthy", "heaven", "hell"}) {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+	})
+
+	t.Run("All yields keys that don't share memory", func(t *testing.T) {
+		var keys [][]rune
+		for key := range tst.All([]rune("hell")) {
+			keys = append(keys, key)
+		}
+		if string(keys[0]) != "hell" || string(keys[1]) != "hello" {
+			t.Fatalf("keys were overwritten: %v, %v", string(keys[0]), string(keys[1]))
+		}
+	})
+
+	t.Run("Scan doesn't allocate per entry", func(t *testing.T) {
+		tst := NewTernarySearchTree[byte, int]()
+		for i := 0; i < 1000; i++ {
+			if err := tst.Insert([]byte(fmt.Sprint(i)), i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		allocs := testing.AllocsPerRun(10, func() {
+			for range tst.Scan(nil) {
+			}
+		})
+		if allocs > 10 {
+			t.Fatalf("too many allocations: %v", allocs)
+		}
+	})
+}
+
 func TestTernarySearchTree_Delete(t *testing.T) {
 	t.Run("The tree can contain different keys", func(t *testing.T) {
 		tst := NewTernarySearchTree[rune, int]()
@@ -315,6 +499,172 @@ func TestTernarySearchTree_Delete(t *testing.T) {
 	})
 }
 
+func TestTernarySearchTree_DeletePrunesNodes(t *testing.T) {
+	t.Run("A prefix that isn't a key can't be deleted", func(t *testing.T) {
+		tst := NewTernarySearchTree[rune, int]()
+		if err := tst.Insert([]rune("hello"), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if val, ok := tst.Delete([]rune("hell")); ok {
+			t.Fatalf("unexpected result. want: 0, false, got: %v, %v", val, ok)
+		}
+		if tst.Len() != 1 {
+			t.Fatalf("unexpected length. want: 1, got: %v", tst.Len())
+		}
+		if val, ok := tst.Search([]rune("hello")); !ok || val != 1 {
+			t.Fatalf("unexpected result. want: 1, true, got: %v, %v", val, ok)
+		}
+	})
+
+	t.Run("Deleting every key empties the tree", func(t *testing.T) {
+		keys := []string{"hello", "hell", "hello😺", "heaven", "healthy", "world", "a"}
+		tst := NewTernarySearchTree[rune, int]()
+		for i, key := range keys {
+			if err := tst.Insert([]rune(key), i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, key := range keys {
+			if val, ok := tst.Delete([]rune(key)); !ok || val != i {
+				t.Fatalf("unexpected result. want: %v, true, got: %v, %v", i, val, ok)
+			}
+			testTSTStructure(t, tst)
+			if tst.Len() != len(keys)-i-1 {
+				t.Fatalf("unexpected length. want: %v, got: %v", len(keys)-i-1, tst.Len())
+			}
+			for _, k := range keys[i+1:] {
+				if _, ok := tst.Search([]rune(k)); !ok {
+					t.Fatalf("%v was lost by deleting %v", k, key)
+				}
+			}
+		}
+		if tst.root != nil || tst.maxKeyLen != 0 {
+			t.Fatalf("an empty tree must have no nodes: root: %+v, max key length: %v", tst.root, tst.maxKeyLen)
+		}
+	})
+
+	t.Run("The maximum key length shrinks when the longest key is deleted", func(t *testing.T) {
+		tst := NewTernarySearchTree[rune, int]()
+		for i, key := range []string{"ab", "abcde", "xyz", "vwxyz"} {
+			if err := tst.Insert([]rune(key), i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tst.Delete([]rune("abcde"))
+		if tst.maxKeyLen != 5 {
+			t.Fatalf("unexpected max key length. want: 5, got: %v", tst.maxKeyLen)
+		}
+		tst.Delete([]rune("vwxyz"))
+		if tst.maxKeyLen != 3 {
+			t.Fatalf("unexpected max key length. want: 3, got: %v", tst.maxKeyLen)
+		}
+		if keys := tst.Keys([]rune("ab")); len(keys) != 1 || string(keys[0]) != "ab" {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+	})
+
+	t.Run("Random insertions and deletions keep the tree consistent", func(t *testing.T) {
+		r := rand.New(rand.NewSource(7))
+		tst := NewTernarySearchTree[byte, int]()
+		entries := map[string]int{}
+		for i := 0; i < 5000; i++ {
+			key := make([]byte, r.Intn(6)+1)
+			for j := range key {
+				key[j] = "abcd"[r.Intn(4)]
+			}
+			if r.Intn(2) == 0 {
+				err := tst.Insert(key, i)
+				if _, exists := entries[string(key)]; exists != errors.Is(err, ErrKeyExists) {
+					t.Fatalf("unexpected error of inserting %s: %v", key, err)
+				}
+				if err == nil {
+					entries[string(key)] = i
+				}
+			} else {
+				val, ok := tst.Delete(key)
+				ev, eok := entries[string(key)]
+				if ok != eok || val != ev {
+					t.Fatalf("unexpected result of deleting %s. want: %v, %v, got: %v, %v", key, ev, eok, val, ok)
+				}
+				delete(entries, string(key))
+			}
+		}
+		testTSTStructure(t, tst)
+		if tst.Len() != len(entries) {
+			t.Fatalf("unexpected length. want: %v, got: %v", len(entries), tst.Len())
+		}
+		maxKeyLen := 0
+		for k := range entries {
+			maxKeyLen = max(maxKeyLen, len(k))
+		}
+		if tst.maxKeyLen != maxKeyLen {
+			t.Fatalf("unexpected max key length. want: %v, got: %v", maxKeyLen, tst.maxKeyLen)
+		}
+	})
+}
+
+func TestTernarySearchTree_Compact(t *testing.T) {
+	tst := NewTernarySearchTree[rune, int]()
+	keys := []string{}
+	for c := 'a'; c <= 'z'; c++ {
+		keys = append(keys, string(c), string(c)+"x")
+	}
+	for i, key := range keys {
+		if err := tst.Insert([]rune(key), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tst.Compact()
+	testTSTStructure(t, tst)
+
+	// 26 siblings form a balanced binary search tree whose height is 5.
+	var height func(n *tsNode[rune, int]) int
+	height = func(n *tsNode[rune, int]) int {
+		if n == nil {
+			return 0
+		}
+		return max(height(n.lt), height(n.gt)) + 1
+	}
+	if h := height(tst.root); h != 5 {
+		t.Fatalf("unexpected height of siblings. want: 5, got: %v", h)
+	}
+	for i, key := range keys {
+		if val, ok := tst.Search([]rune(key)); !ok || val != i {
+			t.Fatalf("unexpected result of searching %v. want: %v, true, got: %v, %v", key, i, val, ok)
+		}
+	}
+	if tst.Len() != len(keys) {
+		t.Fatalf("unexpected length. want: %v, got: %v", len(keys), tst.Len())
+	}
+}
+
+// testTSTStructure checks that siblings of every node are ordered and that every node leads to at least one key.
+func testTSTStructure[K constraints.Ordered, V any](t *testing.T, tst *TernarySearchTree[K, V]) {
+	t.Helper()
+
+	var check func(n *tsNode[K, V], lo, hi *K) int
+	check = func(n *tsNode[K, V], lo, hi *K) int {
+		if n == nil {
+			return 0
+		}
+		if (lo != nil && n.split <= *lo) || (hi != nil && n.split >= *hi) {
+			t.Fatalf("a node %v is out of order among its siblings", n.split)
+		}
+		keys := check(n.eq, nil, nil)
+		if n.end {
+			keys++
+		}
+		if keys == 0 {
+			t.Fatalf("a node %v leads to no key", n.split)
+		}
+		return keys + check(n.lt, lo, &n.split) + check(n.gt, &n.split, hi)
+	}
+	if keys := check(tst.root, nil, nil); keys != tst.count {
+		t.Fatalf("unexpected number of keys. want: %v, got: %v", tst.count, keys)
+	}
+}
+
 func testTSTKeys[K constraints.Ordered, P any](t *testing.T, actual, expected [][]K, prettier func([]K) P) {
 	t.Helper()
 